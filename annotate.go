@@ -0,0 +1,100 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+)
+
+// Brace draws a curly brace spanning x1 to x2 along y, with its point at the midpoint, depth
+// away from y (below, or to the right, for positive depths). Vertical braces span x1 to x2 down
+// the page, at the horizontal position y. The brace is unfilled, unless s sets the fill.
+func (svg *SVG) Brace(x1, y, x2 int, depth int, vertical bool, s ...string) {
+	a, b := order(x1, x2)
+	h := depth / 2
+	q := h
+	if q < 0 {
+		q = -q
+	}
+	if q > (b-a)/4 {
+		q = (b - a) / 4
+	}
+	m := (a + b) / 2
+	pt := across(y, vertical)
+	d := fmt.Sprintf("M%s Q%s %s L%s Q%s %s Q%s %s L%s Q%s %s",
+		pt(a, 0), pt(a, h), pt(a+q, h), pt(m-q, h), pt(m, h), pt(m, depth),
+		pt(m, h), pt(m+q, h), pt(b-q, h), pt(b, h), pt(b, 0))
+	svg.Path(d, append([]string{"fill:none"}, s...)...)
+}
+
+// Bracket draws a square bracket spanning x1 to x2 along y, with a tick at the midpoint,
+// depth away from y, as for Brace. The bracket is unfilled, unless s sets the fill.
+func (svg *SVG) Bracket(x1, y, x2 int, depth int, vertical bool, s ...string) {
+	a, b := order(x1, x2)
+	h := depth / 2
+	m := (a + b) / 2
+	pt := across(y, vertical)
+	d := fmt.Sprintf("M%s L%s L%s L%s M%s L%s",
+		pt(a, 0), pt(a, h), pt(b, h), pt(b, 0), pt(m, h), pt(m, depth))
+	svg.Path(d, append([]string{"fill:none"}, s...)...)
+}
+
+// AnnotationArrow draws an arrow from fromX,fromY to toX,toY, curved to one side by curvature,
+// a fraction of its length (to the left of the direction of travel, for positive values), with
+// label next to the tail. The arrow and label are grouped, with the group styled by s;
+// the arrowhead and label take the fill of the group, and the curve its stroke.
+func (svg *SVG) AnnotationArrow(fromX, fromY, toX, toY int, curvature float64, label string, s ...string) {
+	dx, dy := float64(toX-fromX), float64(toY-fromY)
+	cx := int(math.Round(float64(fromX+toX)/2 + dy*curvature))
+	cy := int(math.Round(float64(fromY+toY)/2 - dx*curvature))
+	unlock := svg.lock()
+	head := svg.uid("arrow")
+	unlock()
+	svg.Group(append([]string{"stroke:black"}, s...)...)
+	defer svg.Gend()
+	svg.Marker(head, 10, 5, 6, 6, `viewBox="0 0 10 10"`, `orient="auto"`)
+	svg.Path("M0,0 L10,5 L0,10 z", "stroke:none")
+	svg.MarkerEnd()
+	svg.Qbez(fromX, fromY, cx, cy, toX, toY, "fill:none", fmt.Sprintf(`marker-end="url(#%s)"`, head))
+	if label == "" {
+		return
+	}
+	tx, ty := float64(cx-fromX), float64(cy-fromY)
+	if tx == 0 && ty == 0 {
+		tx, ty = dx, dy
+	}
+	anchor, lx, ly := "middle", fromX, fromY
+	switch {
+	case math.Abs(tx) >= math.Abs(ty) && tx >= 0:
+		anchor, lx = "end", fromX-labelgap
+	case math.Abs(tx) >= math.Abs(ty):
+		anchor, lx = "start", fromX+labelgap
+	case ty > 0:
+		ly = fromY - labelgap
+	default:
+		ly = fromY + labelgap
+	}
+	baseline := "middle"
+	if anchor == "middle" && ty > 0 {
+		baseline = "text-after-edge"
+	} else if anchor == "middle" {
+		baseline = "text-before-edge"
+	}
+	svg.Text(lx, ly, label, "stroke:none;text-anchor:"+anchor+";dominant-baseline:"+baseline)
+}
+
+// across returns a function giving the coordinates of the point at u along, and v across,
+// a line at position at, which is vertical if vertical is set, otherwise horizontal
+func across(at int, vertical bool) func(u, v int) string {
+	if vertical {
+		return func(u, v int) string { return coord(at+v, u) }
+	}
+	return func(u, v int) string { return coord(u, at+v) }
+}
+
+// order returns a and b in ascending order
+func order(a, b int) (int, int) {
+	if a > b {
+		return b, a
+	}
+	return a, b
+}
@@ -0,0 +1,322 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Scaler maps data values to positions along an axis, and chooses its tick marks
+type Scaler interface {
+	X(v float64) int
+	Extent() (px, pw int)
+	Ticks() (major, minor []float64)
+}
+
+// Linear maps the values Min-Max linearly to the pixels Px to Px+Pw
+type Linear struct {
+	Min, Max float64
+	Px, Pw   int
+}
+
+// Log maps the values Min-Max logarithmically to the pixels Px to Px+Pw
+type Log struct {
+	Min, Max float64
+	Px, Pw   int
+	warnings []string
+}
+
+const (
+	axistick  = 8
+	axisminor = 4
+	axislabel = 20
+)
+
+// Scalemap makes a linear scale from the values minV-maxV to the pixels px to px+pw
+func Scalemap(minV, maxV float64, px, pw int) *Linear {
+	return &Linear{Min: minV, Max: maxV, Px: px, Pw: pw}
+}
+
+// X returns the position of the value v
+func (l *Linear) X(v float64) int {
+	if l.Max == l.Min {
+		return l.Px
+	}
+	return l.Px + int(math.Round((v-l.Min)/(l.Max-l.Min)*float64(l.Pw)))
+}
+
+// Extent returns the start and length of the scale in pixels
+func (l *Linear) Extent() (px, pw int) { return l.Px, l.Pw }
+
+// Ticks returns major ticks at round values, about five across the scale, with no minor ticks
+func (l *Linear) Ticks() (major, minor []float64) {
+	lo, hi := math.Min(l.Min, l.Max), math.Max(l.Min, l.Max)
+	if lo == hi {
+		return []float64{lo}, nil
+	}
+	step := nicestep((hi - lo) / 5)
+	for v := math.Ceil(lo/step) * step; v <= hi+step/1e9; v += step {
+		major = append(major, v)
+	}
+	return major, nil
+}
+
+// LogScale makes a logarithmic scale from the values minV-maxV to the pixels px to px+pw.
+// Both values must be positive.
+func LogScale(minV, maxV float64, px, pw int) *Log {
+	return &Log{Min: minV, Max: maxV, Px: px, Pw: pw}
+}
+
+// X returns the position of the value v. Values at or below zero, or below the minimum,
+// clamp to the start of the scale with a warning.
+func (l *Log) X(v float64) int {
+	if l.Min <= 0 || l.Max <= l.Min {
+		return l.Px
+	}
+	if v < l.Min {
+		l.warnings = append(l.warnings, fmt.Sprintf("log scale: %g below minimum %g", v, l.Min))
+		return l.Px
+	}
+	f := (math.Log10(v) - math.Log10(l.Min)) / (math.Log10(l.Max) - math.Log10(l.Min))
+	return l.Px + int(math.Round(f*float64(l.Pw)))
+}
+
+// Extent returns the start and length of the scale in pixels
+func (l *Log) Extent() (px, pw int) { return l.Px, l.Pw }
+
+// Ticks returns major ticks at each power of ten, and minor ticks
+// at two to nine times each power of ten, within the scale
+func (l *Log) Ticks() (major, minor []float64) {
+	if l.Min <= 0 || l.Max <= l.Min {
+		return nil, nil
+	}
+	for e := math.Floor(math.Log10(l.Min)); e <= math.Ceil(math.Log10(l.Max)); e++ {
+		d := math.Pow(10, e)
+		if d >= l.Min && d <= l.Max {
+			major = append(major, d)
+		}
+		for m := 2.0; m < 10; m++ {
+			if v := m * d; v >= l.Min && v <= l.Max {
+				minor = append(minor, v)
+			}
+		}
+	}
+	return major, minor
+}
+
+// Warnings returns the warnings recorded while mapping values
+func (l *Log) Warnings() []string { return l.warnings }
+
+// Axis draws a horizontal axis at y for the scale sc, with tick marks below the axis,
+// and major ticks labeled with their SI formatted values. The axis is styled by s.
+func (svg *SVG) Axis(y int, sc Scaler, s ...string) {
+	px, pw := sc.Extent()
+	major, minor := sc.Ticks()
+	if len(s) == 0 {
+		s = []string{"stroke:black;font-size:10px;text-anchor:middle"}
+	}
+	svg.Group(s...)
+	svg.Line(px, y, px+pw, y)
+	for _, v := range minor {
+		x := sc.X(v)
+		svg.Line(x, y, x, y+axisminor)
+	}
+	for _, v := range major {
+		x := sc.X(v)
+		svg.Line(x, y, x, y+axistick)
+		svg.Text(x, y+axislabel, SI(v), "stroke:none")
+	}
+	svg.Gend()
+}
+
+// timestep is a candidate spacing of time axis ticks, n units apart
+type timestep struct {
+	unit   string
+	n      int
+	approx time.Duration
+}
+
+const (
+	timeday  = 24 * time.Hour
+	timeyear = 365 * timeday
+)
+
+// timeweeks is the Monday from which weeks are counted, to align ticks more than a week apart
+var timeweeks = time.Date(1970, 1, 5, 0, 0, 0, 0, time.UTC)
+
+var timesteps = []timestep{
+	{"second", 1, time.Second}, {"second", 5, 5 * time.Second}, {"second", 15, 15 * time.Second}, {"second", 30, 30 * time.Second},
+	{"minute", 1, time.Minute}, {"minute", 5, 5 * time.Minute}, {"minute", 15, 15 * time.Minute}, {"minute", 30, 30 * time.Minute},
+	{"hour", 1, time.Hour}, {"hour", 3, 3 * time.Hour}, {"hour", 6, 6 * time.Hour}, {"hour", 12, 12 * time.Hour},
+	{"day", 1, timeday}, {"day", 2, 2 * timeday}, {"week", 1, 7 * timeday}, {"week", 2, 14 * timeday},
+	{"month", 1, 30 * timeday}, {"month", 3, 91 * timeday}, {"month", 6, 182 * timeday},
+	{"year", 1, timeyear}, {"year", 2, 2 * timeyear}, {"year", 5, 5 * timeyear}, {"year", 10, 10 * timeyear},
+}
+
+var timeformats = map[string]string{
+	"second": "15:04:05",
+	"minute": "15:04",
+	"hour":   "15:04",
+	"day":    "Jan 2",
+	"week":   "Jan 2",
+	"month":  "Jan 2006",
+	"year":   "2006",
+}
+
+// TimeAxis draws a horizontal time axis of the specified length at x,y, spanning start to end.
+// Ticks are placed at natural boundaries in the location loc (the location of start if nil)
+// of a unit chosen for about four to ten ticks: "second", "minute", "hour", "day", "week" (beginning
+// on Mondays), "month" or "year".
+// Labels are made by format, or a default layout for the unit if it is nil.
+// The axis is styled by s, and TimeAxis returns the unit.
+func (svg *SVG) TimeAxis(x, y, length int, start, end time.Time, loc *time.Location, format func(t time.Time, unit string) string, s ...string) string {
+	if loc == nil {
+		loc = start.Location()
+	}
+	if format == nil {
+		format = func(t time.Time, unit string) string { return t.Format(timeformats[unit]) }
+	}
+	if len(s) == 0 {
+		s = []string{"stroke:black;font-size:10px;text-anchor:middle"}
+	}
+	span := end.Sub(start)
+	st := timestepfor(start, end, loc)
+	svg.Group(s...)
+	svg.Line(x, y, x+length, y)
+	if span > 0 {
+		for _, t := range timeticks(start, end, loc, st) {
+			tx := x + int(math.Round(float64(t.Sub(start))/float64(span)*float64(length)))
+			svg.Line(tx, y, tx, y+axistick)
+			svg.Text(tx, y+axislabel, format(t, st.unit), "stroke:none")
+		}
+	}
+	svg.Gend()
+	return st.unit
+}
+
+// timestepfor chooses the finest tick spacing giving at most ten ticks from start to end in loc,
+// or, if that gives fewer than four, the next finer spacing
+func timestepfor(start, end time.Time, loc *time.Location) timestep {
+	span := end.Sub(start)
+	for i, st := range timesteps {
+		if span/st.approx > 10 {
+			continue
+		}
+		n := len(timeticks(start, end, loc, st))
+		if n > 10 {
+			continue
+		}
+		if n < 4 && i > 0 {
+			return timesteps[i-1]
+		}
+		return st
+	}
+	n := int(nicestep(float64(span) / float64(timeyear) / 10))
+	return timestep{"year", n, time.Duration(n) * timeyear}
+}
+
+// timeticks returns the boundaries of the unit of st, n units apart, from start to end.
+// Boundaries are found in wall clock time in loc, so that daylight saving transitions
+// do not add or remove ticks.
+func timeticks(start, end time.Time, loc *time.Location, st timestep) []time.Time {
+	s := start.In(loc)
+	var t time.Time
+	switch st.unit {
+	case "second":
+		t = s.Truncate(time.Second)
+	case "minute":
+		t = s.Truncate(time.Minute)
+	case "hour":
+		t = time.Date(s.Year(), s.Month(), s.Day(), s.Hour(), 0, 0, 0, loc)
+	case "day":
+		t = time.Date(s.Year(), s.Month(), s.Day(), 0, 0, 0, 0, loc)
+	case "week":
+		t = time.Date(s.Year(), s.Month(), s.Day()-(int(s.Weekday())+6)%7, 0, 0, 0, 0, loc)
+	case "month":
+		t = time.Date(s.Year(), s.Month(), 1, 0, 0, 0, 0, loc)
+	default:
+		t = time.Date(s.Year(), 1, 1, 0, 0, 0, 0, loc)
+	}
+	var ticks []time.Time
+	for ; !t.After(end); t = timenext(t, st.unit) {
+		if !t.Before(start) && timefield(t, st.unit)%st.n == 0 {
+			ticks = append(ticks, t)
+		}
+	}
+	return ticks
+}
+
+// timenext returns the next boundary of the unit after t
+func timenext(t time.Time, unit string) time.Time {
+	switch unit {
+	case "second":
+		return t.Add(time.Second)
+	case "minute":
+		return t.Add(time.Minute)
+	case "hour":
+		return t.Add(time.Hour)
+	case "day":
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	case "week":
+		return time.Date(t.Year(), t.Month(), t.Day()+7, 0, 0, 0, 0, t.Location())
+	case "month":
+		return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(t.Year()+1, 1, 1, 0, 0, 0, 0, t.Location())
+}
+
+// timefield returns the zero based field of t for the unit, used to align ticks
+func timefield(t time.Time, unit string) int {
+	switch unit {
+	case "second":
+		return t.Second()
+	case "minute":
+		return t.Minute()
+	case "hour":
+		return t.Hour()
+	case "day":
+		return t.Day() - 1
+	case "week":
+		return int(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Sub(timeweeks) / (7 * timeday))
+	case "month":
+		return int(t.Month()) - 1
+	}
+	return t.Year()
+}
+
+// SI formats v with up to three significant digits and an SI prefix, for example 1.5k or 20µ
+func SI(v float64) string {
+	const prefixes = "pnµm kMGT"
+	if v == 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	p := []rune(prefixes)
+	i := 4 + int(math.Floor(math.Log10(math.Abs(v))/3))
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(p) {
+		i = len(p) - 1
+	}
+	m := v / math.Pow(1000, float64(i-4))
+	m, _ = strconv.ParseFloat(strconv.FormatFloat(m, 'g', 3, 64), 64)
+	if i == 4 {
+		return strconv.FormatFloat(m, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(m, 'f', -1, 64) + string(p[i])
+}
+
+// nicestep rounds a step up to 1, 2 or 5 times a power of ten
+func nicestep(step float64) float64 {
+	d := math.Pow(10, math.Floor(math.Log10(step)))
+	switch f := step / d; {
+	case f <= 1:
+		return d
+	case f <= 2:
+		return 2 * d
+	case f <= 5:
+		return 5 * d
+	}
+	return 10 * d
+}
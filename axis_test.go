@@ -0,0 +1,124 @@
+package svg
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// timeaxis draws a time axis from start to end in loc, and returns its unit, and the positions and
+// labels of its ticks, labeled with their wall clock time in loc
+func timeaxis(t *testing.T, start, end time.Time, loc *time.Location) (unit string, xs []int, labels []string) {
+	t.Helper()
+	doc := render(t, func(canvas *SVG) {
+		unit = canvas.TimeAxis(0, 50, 1000, start, end, loc, func(tick time.Time, unit string) string {
+			return tick.In(loc).Format("2006-01-02 15:04 MST")
+		})
+	})
+	var parsed struct {
+		Texts []struct {
+			X    int    `xml:"x,attr"`
+			Text string `xml:",chardata"`
+		} `xml:"g>text"`
+	}
+	if err := xml.Unmarshal([]byte(doc), &parsed); err != nil {
+		t.Fatalf("%v in\n%s", err, doc)
+	}
+	for _, e := range parsed.Texts {
+		xs = append(xs, e.X)
+		labels = append(labels, e.Text)
+	}
+	return unit, xs, labels
+}
+
+func TestTimeAxis(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		name       string
+		start, end time.Time
+		unit       string
+		labels     []string
+	}{
+		{
+			"90 minutes, into summer time",
+			time.Date(2024, 3, 31, 1, 30, 0, 0, berlin), time.Date(2024, 3, 31, 1, 30, 0, 0, berlin).Add(90 * time.Minute),
+			"minute",
+			[]string{"2024-03-31 01:30 CET", "2024-03-31 01:45 CET", "2024-03-31 03:00 CEST", "2024-03-31 03:15 CEST",
+				"2024-03-31 03:30 CEST", "2024-03-31 03:45 CEST", "2024-03-31 04:00 CEST"},
+		},
+		{
+			"90 minutes, out of summer time",
+			time.Date(2024, 10, 27, 2, 0, 0, 0, berlin).Add(-time.Hour), time.Date(2024, 10, 27, 2, 0, 0, 0, berlin).Add(30 * time.Minute),
+			"minute",
+			[]string{"2024-10-27 02:00 CEST", "2024-10-27 02:15 CEST", "2024-10-27 02:30 CEST", "2024-10-27 02:45 CEST",
+				"2024-10-27 02:00 CET", "2024-10-27 02:15 CET", "2024-10-27 02:30 CET"},
+		},
+		{
+			"3 days, into summer time",
+			time.Date(2024, 3, 30, 0, 0, 0, 0, berlin), time.Date(2024, 4, 2, 0, 0, 0, 0, berlin),
+			"hour",
+			[]string{"2024-03-30 00:00 CET", "2024-03-30 12:00 CET", "2024-03-31 00:00 CET", "2024-03-31 12:00 CEST",
+				"2024-04-01 00:00 CEST", "2024-04-01 12:00 CEST", "2024-04-02 00:00 CEST"},
+		},
+		{
+			"3 days, out of summer time",
+			time.Date(2024, 10, 26, 0, 0, 0, 0, berlin), time.Date(2024, 10, 29, 0, 0, 0, 0, berlin),
+			"hour",
+			[]string{"2024-10-26 00:00 CEST", "2024-10-26 12:00 CEST", "2024-10-27 00:00 CEST", "2024-10-27 12:00 CET",
+				"2024-10-28 00:00 CET", "2024-10-28 12:00 CET", "2024-10-29 00:00 CET"},
+		},
+		{
+			"14 months, through both transitions",
+			time.Date(2024, 1, 15, 0, 0, 0, 0, berlin), time.Date(2025, 3, 15, 0, 0, 0, 0, berlin),
+			"month",
+			[]string{"2024-04-01 00:00 CEST", "2024-07-01 00:00 CEST", "2024-10-01 00:00 CEST", "2025-01-01 00:00 CET"},
+		},
+	} {
+		unit, xs, labels := timeaxis(t, c.start, c.end, berlin)
+		if unit != c.unit {
+			t.Errorf("%s: unit %s, want %s", c.name, unit, c.unit)
+		}
+		if strings.Join(labels, ", ") != strings.Join(c.labels, ", ") {
+			t.Errorf("%s: ticks\n%s\nwant\n%s", c.name, strings.Join(labels, "\n"), strings.Join(c.labels, "\n"))
+		}
+		for i := 1; i < len(xs); i++ {
+			if xs[i] <= xs[i-1] {
+				t.Errorf("%s: tick %d at %d, not after %d", c.name, i, xs[i], xs[i-1])
+			}
+		}
+	}
+}
+
+func TestTimeAxisTickCount(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 2, 7, 9, 41, 0, 0, berlin)
+	for _, days := range []int{30, 45, 60, 90, 120} {
+		unit, _, labels := timeaxis(t, start, start.AddDate(0, 0, days), berlin)
+		if unit != "week" {
+			t.Errorf("%d days: unit %s, want week", days, unit)
+		}
+		if len(labels) < 4 || len(labels) > 10 {
+			t.Errorf("%d days: %d ticks, want 4 to 10: %q", days, len(labels), labels)
+		}
+		for _, l := range labels {
+			if d, _ := time.ParseInLocation("2006-01-02 15:04 MST", l, berlin); d.Weekday() != time.Monday || d.Hour() != 0 {
+				t.Errorf("%d days: tick %s not at the start of a week", days, l)
+			}
+		}
+	}
+	for _, span := range []time.Duration{time.Minute, 10 * time.Minute, 7 * time.Hour, 50 * time.Hour, 10 * timeday,
+		200 * timeday, 3 * timeyear, 40 * timeyear} {
+		_, _, labels := timeaxis(t, start, start.Add(span), berlin)
+		if len(labels) < 4 || len(labels) > 20 {
+			t.Errorf("%v: %d ticks, want 4 to 20: %q", span, len(labels), labels)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package svg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BadgeOpts specifies the appearance of a badge. Zero values give defaults: a fully rounded
+// pill, badgeheight high, with text widths estimated from the number of characters.
+type BadgeOpts struct {
+	Fill      string           // fill color of the pill
+	TextStyle string           // style of the text
+	Height    int              // height of the pill
+	Radius    int              // corner radius; zero rounds the ends fully
+	Padding   int              // horizontal space either side of the contents
+	Icon      string           // id of a symbol drawn, square, before the text, if any
+	MaxWidth  int              // if positive, text is shortened with an ellipsis to fit the badge in this width
+	Measure   func(string) int // width of text in the text style
+}
+
+const (
+	badgeheight  = 20
+	badgepadding = 8
+	ellipsis     = "…"
+)
+
+// Badge draws a pill at x,y containing the icon and text specified by opts,
+// with its contents centered vertically, and returns its width, so that badges may be laid out in a row.
+func (svg *SVG) Badge(x, y int, text string, opts BadgeOpts) (width int) {
+	h := opts.Height
+	if h <= 0 {
+		h = badgeheight
+	}
+	pad := opts.Padding
+	if pad <= 0 {
+		pad = badgepadding
+	}
+	r := opts.Radius
+	if r <= 0 {
+		r = h / 2
+	}
+	measure := opts.Measure
+	if measure == nil {
+		measure = func(s string) int { return len(graphemes(s)) * h / 2 }
+	}
+	icon, gap := 0, 0
+	if opts.Icon != "" {
+		icon = h * 3 / 5
+		if text != "" {
+			gap = h / 4
+		}
+	}
+	if opts.MaxWidth > 0 {
+		text = shorten(text, opts.MaxWidth-2*pad-icon-gap, measure)
+		if text == "" {
+			gap = 0
+		}
+	}
+	width = 2*pad + icon + gap + measure(text)
+	fill := ""
+	if opts.Fill != "" {
+		fill = "fill:" + opts.Fill
+	}
+	svg.Roundrect(x, y, width, h, r, r, fill)
+	if opts.Icon != "" {
+		svg.Use(x+pad, y+svg.CenterOffset(h, icon), "#"+opts.Icon, fmt.Sprintf(`width="%d" height="%d"`, icon, icon))
+	}
+	if text != "" {
+		svg.Text(x+pad+icon+gap, y+half(h, svg.rounding), text, "dominant-baseline:central", opts.TextStyle)
+	}
+	return width
+}
+
+// shorten cuts t, between characters, so that it and a trailing ellipsis are no wider than w
+// as measured by measure. Text that fits is returned whole.
+func shorten(t string, w int, measure func(string) int) string {
+	if measure(t) <= w {
+		return t
+	}
+	g := graphemes(t)
+	for n := len(g) - 1; n > 0; n-- {
+		s := strings.Join(g[:n], "") + ellipsis
+		if measure(s) <= w {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package svg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrMismatchedSegments is returned when bar segments and their fills differ in length
+var ErrMismatchedSegments = errors.New("svg: segments and fills differ in length")
+
+// ProgressBar draws a progress bar at x,y with dimension w,h, filled to fraction (clamped to 0-1)
+// with the color fg, over a background of the color bg. Rounded bars have semicircular ends,
+// which clip the filled portion. The bar is grouped, with the group styled by s.
+func (svg *SVG) ProgressBar(x, y, w, h int, fraction float64, fg, bg string, rounded bool, s ...string) {
+	fraction = math.Max(0, math.Min(1, fraction))
+	fw := int(math.Round(fraction * float64(w)))
+	svg.Group(s...)
+	if !rounded {
+		svg.Rect(x, y, w, h, "fill:"+bg)
+		svg.Rect(x, y, fw, h, "fill:"+fg)
+		svg.Gend()
+		return
+	}
+	unlock := svg.lock()
+	clip := svg.uid("progress")
+	unlock()
+	r := h / 2
+	svg.WithClip(clip, func() { svg.Roundrect(x, y, w, h, r, r) })
+	svg.Roundrect(x, y, w, h, r, r, "fill:"+bg)
+	svg.Rect(x, y, fw, h, "fill:"+fg, fmt.Sprintf(`clip-path="url(#%s)"`, clip))
+	svg.Gend()
+}
+
+// SegmentBar draws a bar at x,y with dimension w,h divided into segments in proportion to
+// their values, each filled with the corresponding color in fills, and separated by gap.
+// The bar is grouped, with the group styled by s. If segments and fills differ in length,
+// nothing is drawn, and ErrMismatchedSegments is returned.
+func (svg *SVG) SegmentBar(x, y, w, h int, segments []float64, fills []string, gap int, s ...string) error {
+	if len(segments) != len(fills) {
+		return ErrMismatchedSegments
+	}
+	total := 0.0
+	for _, v := range segments {
+		total += math.Max(0, v)
+	}
+	svg.Group(s...)
+	defer svg.Gend()
+	avail := float64(w - gap*(len(segments)-1))
+	if total <= 0 || avail <= 0 {
+		return nil
+	}
+	at := 0.0
+	for i, v := range segments {
+		start := int(math.Round(at))
+		at += math.Max(0, v) / total * avail
+		if sw := int(math.Round(at)) - start; sw > 0 {
+			svg.Rect(x+start+i*gap, y, sw, h, "fill:"+fills[i])
+		}
+	}
+	return nil
+}
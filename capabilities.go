@@ -0,0 +1,115 @@
+package svg
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNotSeekable is returned by operations that need a canvas writing to an io.WriteSeeker
+var ErrNotSeekable = errors.New("svg: canvas is not writing to a seekable writer")
+
+// sizepad is the space reserved after the width and height of the root element of
+// documents written to seekable writers, so that they may be rewritten at End
+const sizepad = 16
+
+// Capabilities describes what the writer of a canvas allows
+type Capabilities struct {
+	Buffered  bool // the document is kept in memory, and may be revised (NewBuffer)
+	Seekable  bool // the writer is an io.WriteSeeker, and the document size may be revised
+	Flushable bool // output is buffered before the writer, and written out by Flush and End
+}
+
+// flusher is a writer that buffers its output
+type flusher interface {
+	Flush() error
+}
+
+// Capabilities reports what the writer of the canvas allows
+func (svg *SVG) Capabilities() Capabilities {
+	return Capabilities{Buffered: svg.doc != nil, Seekable: svg.seeker != nil, Flushable: svg.buffer != nil || flushable(svg.Writer)}
+}
+
+// flushable determines if w buffers its output, looking through the writers the package
+// places in front of the caller's writer, which only flush what they are given
+func flushable(w io.Writer) bool {
+	switch w := w.(type) {
+	case *indenter:
+		return flushable(w.w)
+	case *tee:
+		return w.primary != nil && w.primary.Capabilities().Flushable ||
+			w.secondary != nil && w.secondary.Capabilities().Flushable
+	}
+	_, ok := w.(flusher)
+	return ok
+}
+
+// PatchSize sets the width and height, in unit, of a document written to a seekable writer,
+// overwriting those given when it was started once it ends. This allows the size of a document
+// to depend on what is drawn. Canvases not writing to an io.WriteSeeker return ErrNotSeekable.
+func (svg *SVG) PatchSize(w, h int, unit string) error {
+	defer svg.lock()()
+	if svg.seeker == nil {
+		return ErrNotSeekable
+	}
+	svg.size.patch = fmt.Sprintf(` width="%d%s" height="%d%s"`, w, unit, h, unit)
+	return nil
+}
+
+// rootsize records the position of the root element's width and height
+type rootsize struct {
+	at    int64  // offset of the attributes in the writer, or -1 if there are none
+	n     int    // length of the attributes, and the space after them
+	patch string // attributes to write at End
+}
+
+// reservesize records where the width and height in top will be written to a seekable writer,
+// returning top with space reserved after them
+func (svg *SVG) reservesize(top string) string {
+	svg.size.at = -1
+	if svg.seeker == nil {
+		return top
+	}
+	i := strings.Index(top, ` width="`)
+	j := strings.Index(top, ` height="`)
+	if i < 0 || j < i {
+		return top
+	}
+	k := strings.IndexByte(top[j+len(` height="`):], '"')
+	if k < 0 {
+		return top
+	}
+	end := j + len(` height="`) + k + 1
+	pos, err := svg.seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return top
+	}
+	if svg.buffer != nil {
+		pos += int64(svg.buffer.Buffered())
+	}
+	svg.size.at, svg.size.n = pos+int64(i), end-i+sizepad
+	return top[:end] + strings.Repeat(" ", sizepad) + top[end:]
+}
+
+// patchsize overwrites the width and height of the root element with those set by PatchSize
+func (svg *SVG) patchsize() {
+	if svg.size.patch == "" || svg.seeker == nil {
+		return
+	}
+	if svg.size.at < 0 || len(svg.size.patch) > svg.size.n {
+		svg.warn("size %q cannot be patched", strings.TrimSpace(svg.size.patch))
+		return
+	}
+	end, err := svg.seeker.Seek(0, io.SeekCurrent)
+	if err == nil {
+		_, err = svg.seeker.Seek(svg.size.at, io.SeekStart)
+	}
+	if err == nil {
+		_, err = io.WriteString(svg.seeker, svg.size.patch+strings.Repeat(" ", svg.size.n-len(svg.size.patch)))
+	}
+	if err == nil {
+		_, err = svg.seeker.Seek(end, io.SeekStart)
+	}
+	svg.latch(err)
+}
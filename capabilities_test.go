@@ -0,0 +1,123 @@
+package svg
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// writerkinds makes a canvas of each kind of writer: a stream, a seekable file, and a buffer
+func writerkinds(t *testing.T) map[string]*SVG {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "*.svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return map[string]*SVG{
+		"stream":   New(new(strings.Builder)),
+		"seekable": New(f),
+		"buffered": NewBuffer(),
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "*.svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, c := range []struct {
+		name   string
+		canvas *SVG
+		want   Capabilities
+	}{
+		{"New", New(new(strings.Builder)), Capabilities{}},
+		{"New file", New(f), Capabilities{Seekable: true}},
+		{"NewBuffer", NewBuffer(), Capabilities{Buffered: true}},
+		{"NewBuffered", NewBuffered(new(bytes.Buffer), 4096), Capabilities{Flushable: true}},
+		{"NewBuffered file", NewBuffered(f, 4096), Capabilities{Seekable: true, Flushable: true}},
+		{"New bufio.Writer", New(bufio.NewWriter(new(bytes.Buffer))), Capabilities{Flushable: true}},
+		{"Indent", NewWithOptions(new(bytes.Buffer), Options{Indent: "  "}), Capabilities{}},
+		{"Indent Buffer", NewWithOptions(new(bytes.Buffer), Options{Indent: "  ", Buffer: 64}), Capabilities{Flushable: true}},
+		{"Indent bufio.Writer", NewWithOptions(bufio.NewWriter(new(bytes.Buffer)), Options{Indent: "  "}), Capabilities{Flushable: true}},
+		{"NewTee", NewTee(New(new(bytes.Buffer)), NewBuffer(), nil), Capabilities{}},
+		{"NewTee buffered", NewTee(New(new(bytes.Buffer)), NewBuffered(new(bytes.Buffer), 64), nil), Capabilities{Flushable: true}},
+		{"NewTee nil", NewTee(nil, nil, nil), Capabilities{}},
+	} {
+		if got := c.canvas.Capabilities(); got != c.want {
+			t.Errorf("%s: Capabilities() = %+v, want %+v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestRequiresBuffer(t *testing.T) {
+	for kind, canvas := range writerkinds(t) {
+		want := ErrRequiresBuffer
+		if kind == "buffered" {
+			want = nil
+		}
+		canvas.Start(100, 100)
+		canvas.Text(10, 10, canvas.Placeholder("name"))
+		canvas.End()
+		if err := canvas.ResolvePlaceholders(map[string]string{"name": "value"}); err != want {
+			t.Errorf("%s: ResolvePlaceholders error = %v, want %v", kind, err, want)
+		}
+		if _, err := canvas.ExportRegion(0, 0, 10, 10, true); err != want {
+			t.Errorf("%s: ExportRegion error = %v, want %v", kind, err, want)
+		}
+		if _, err := canvas.FinishMarkup(); err != want {
+			t.Errorf("%s: FinishMarkup error = %v, want %v", kind, err, want)
+		}
+		if _, err := canvas.WriteTo(new(bytes.Buffer)); err != want {
+			t.Errorf("%s: WriteTo error = %v, want %v", kind, err, want)
+		}
+		if items := canvas.ExtractText(false); (len(items) == 1) != (want == nil) {
+			t.Errorf("%s: ExtractText = %v", kind, items)
+		}
+	}
+}
+
+func TestPatchSize(t *testing.T) {
+	for kind, canvas := range writerkinds(t) {
+		want := ErrNotSeekable
+		if kind == "seekable" {
+			want = nil
+		}
+		canvas.Start(100, 100)
+		if err := canvas.PatchSize(300, 200, "px"); err != want {
+			t.Errorf("%s: PatchSize error = %v, want %v", kind, err, want)
+		}
+		canvas.Circle(150, 100, 50)
+		canvas.End()
+		if err := canvas.Err(); err != nil {
+			t.Errorf("%s: Err() = %v", kind, err)
+		}
+	}
+
+	f, err := os.CreateTemp(t.TempDir(), "*.svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	canvas := NewBuffered(f, 16)
+	canvas.Start(100, 100)
+	canvas.Circle(150, 100, 50)
+	if err := canvas.PatchSize(300, 200, "px"); err != nil {
+		t.Fatal(err)
+	}
+	canvas.End()
+	if err := canvas.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	doc, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(doc, []byte(`<svg width="300px" height="200px"`)) {
+		t.Errorf("size not patched in\n%s", doc)
+	}
+	wellformed(t, string(doc))
+}
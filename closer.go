@@ -0,0 +1,51 @@
+package svg
+
+import "sync"
+
+// gend returns a function ending the group just begun, which ends it only the first time it is called
+func (svg *SVG) gend() func() {
+	var once sync.Once
+	return func() { once.Do(svg.Gend) }
+}
+
+// GroupC begins a group like Group, returning the function that ends it
+func (svg *SVG) GroupC(s ...string) func() {
+	svg.Group(s...)
+	return svg.gend()
+}
+
+// GstyleC begins a group like Gstyle, returning the function that ends it
+func (svg *SVG) GstyleC(s string) func() {
+	svg.Gstyle(s)
+	return svg.gend()
+}
+
+// GtransformC begins a group like Gtransform, returning the function that ends it
+func (svg *SVG) GtransformC(s string) func() {
+	svg.Gtransform(s)
+	return svg.gend()
+}
+
+// GidC begins a group like Gid, returning the function that ends it
+func (svg *SVG) GidC(s string) func() {
+	svg.Gid(s)
+	return svg.gend()
+}
+
+// TranslateC begins a translation like Translate, returning the function that ends it
+func (svg *SVG) TranslateC(x, y int) func() {
+	svg.Translate(x, y)
+	return svg.gend()
+}
+
+// ScaleC begins scaling like Scale, returning the function that ends it
+func (svg *SVG) ScaleC(n float64) func() {
+	svg.Scale(n)
+	return svg.gend()
+}
+
+// RotateC begins a rotation like Rotate, returning the function that ends it
+func (svg *SVG) RotateC(r float64) func() {
+	svg.Rotate(r)
+	return svg.gend()
+}
@@ -0,0 +1,42 @@
+package svg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCloserOnce(t *testing.T) {
+	for name, open := range map[string]func(*SVG) func(){
+		"GroupC":      func(c *SVG) func() { return c.GroupC(`id="g"`) },
+		"GstyleC":     func(c *SVG) func() { return c.GstyleC("fill:red") },
+		"GtransformC": func(c *SVG) func() { return c.GtransformC("rotate(10)") },
+		"GidC":        func(c *SVG) func() { return c.GidC("g") },
+		"TranslateC":  func(c *SVG) func() { return c.TranslateC(10, 20) },
+		"ScaleC":      func(c *SVG) func() { return c.ScaleC(2) },
+		"RotateC":     func(c *SVG) func() { return c.RotateC(30) },
+	} {
+		var b strings.Builder
+		canvas := New(&b)
+		canvas.Start(100, 100)
+		outer := canvas.GstyleC("stroke:blue")
+		end := open(canvas)
+		canvas.Circle(50, 50, 10)
+		end()
+		end()
+		canvas.Rect(0, 0, 10, 10)
+		outer()
+		end()
+		outer()
+		if err := canvas.EndChecked(); err != nil {
+			t.Errorf("%s: EndChecked() = %v", name, err)
+		}
+		doc := b.String()
+		if n := strings.Count(doc, "</g>"); n != 2 {
+			t.Errorf("%s: %d group ends, want 2, in\n%s", name, n, doc)
+		}
+		if !strings.Contains(doc, "<circle cx=\"50\" cy=\"50\" r=\"10\"/>\n</g>\n<rect") {
+			t.Errorf("%s: inner group not ended before the rect in\n%s", name, doc)
+		}
+		wellformed(t, doc)
+	}
+}
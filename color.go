@@ -0,0 +1,287 @@
+package svg
+
+//go:generate go run gencolornames.go
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ValidColor determines if s is a valid color: a CSS named color, "none", "currentColor" or
+// "transparent", a hex color (#rgb, #rgba, #rrggbb or #rrggbbaa), or a functional color
+// (rgb(), rgba(), hsl() or hsla())
+func ValidColor(s string) bool {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if _, ok := colornames[s]; ok {
+		return true
+	}
+	switch s {
+	case "none", "currentcolor", "transparent":
+		return true
+	}
+	if strings.HasPrefix(s, "#") {
+		switch len(s) {
+		case 4, 5, 7, 9:
+			_, err := strconv.ParseUint(s[1:], 16, 32)
+			return err == nil
+		}
+		return false
+	}
+	n := strings.IndexByte(s, '(')
+	if n < 0 || !strings.HasSuffix(s, ")") {
+		return false
+	}
+	args, alpha := colorargs(s[n+1 : len(s)-1])
+	if args == nil {
+		return false
+	}
+	switch s[:n] {
+	case "rgb", "rgba":
+		for _, a := range args {
+			if !colornumber(a, true) {
+				return false
+			}
+		}
+	case "hsl", "hsla":
+		if !colornumber(strings.TrimSuffix(args[0], "deg"), false) || !percent(args[1]) || !percent(args[2]) {
+			return false
+		}
+	default:
+		return false
+	}
+	return alpha == "" || colornumber(alpha, true)
+}
+
+// colorargs splits the arguments of a functional color, separated by commas or spaces,
+// into its three components and an optional alpha, returning nil if malformed
+func colorargs(s string) (args []string, alpha string) {
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		s, alpha = s[:i], strings.TrimSpace(s[i+1:])
+		if alpha == "" {
+			return nil, ""
+		}
+	}
+	f := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+	switch {
+	case len(f) == 4 && alpha == "":
+		alpha = f[3]
+	case len(f) != 3:
+		return nil, ""
+	}
+	return f[:3], alpha
+}
+
+// colornumber determines if s is a number, or a percentage if allowed
+func colornumber(s string, pct bool) bool {
+	if pct && strings.HasSuffix(s, "%") {
+		s = s[:len(s)-1]
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// percent determines if s is a percentage
+func percent(s string) bool { return strings.HasSuffix(s, "%") && colornumber(s[:len(s)-1], false) }
+
+// parsecolor returns the red, green and blue components of a color specified
+// by name, in hex (#rgb, #rrggbb) or functional (rgb(r,g,b)) form
+func parsecolor(s string) (r, g, b uint8, ok bool) {
+	s = strings.TrimSpace(s)
+	if h, named := colornames[strings.ToLower(s)]; named {
+		s = h
+	}
+	switch {
+	case strings.HasPrefix(s, "#"):
+		return parsehex(s[1:])
+	case strings.HasPrefix(s, "rgb(") && strings.HasSuffix(s, ")"):
+		return parsergb(s[4 : len(s)-1])
+	}
+	return 0, 0, 0, false
+}
+
+// parsehex parses hex color digits in either the short (rgb) or long (rrggbb) form
+func parsehex(h string) (r, g, b uint8, ok bool) {
+	if len(h) == 3 {
+		h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+	}
+	if len(h) != 6 {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(h, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return uint8(v >> 16), uint8(v >> 8), uint8(v), true
+}
+
+// parsergb parses the comma separated arguments of the rgb() function
+func parsergb(args string) (r, g, b uint8, ok bool) {
+	f := strings.Split(args, ",")
+	if len(f) != 3 {
+		return 0, 0, 0, false
+	}
+	var c [3]uint8
+	for i, v := range f {
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || n < 0 || n > 255 {
+			return 0, 0, 0, false
+		}
+		c[i] = uint8(n)
+	}
+	return c[0], c[1], c[2], true
+}
+
+// RampBetween returns steps colors, in #rrggbb form, evenly interpolated in RGB from c1 to c2
+func RampBetween(c1, c2 string, steps int) []string { return RampBetweenIn(c1, c2, steps, "rgb") }
+
+// RampBetweenIn returns steps colors, in #rrggbb form, evenly interpolated from c1 to c2
+// in the color space named by space: "rgb", "hsl" (taking the shorter way around the hue circle)
+// or "oklab", which avoids the muddy midpoints of RGB between saturated colors.
+// Other spaces interpolate in RGB. If either color cannot be parsed, RampBetweenIn returns nil.
+func RampBetweenIn(c1, c2 string, steps int, space string) []string {
+	r1, g1, b1, ok1 := parsecolor(c1)
+	r2, g2, b2, ok2 := parsecolor(c2)
+	if !ok1 || !ok2 || steps < 1 {
+		return nil
+	}
+	var from, to [3]float64
+	var back func(c [3]float64) (r, g, b uint8)
+	switch space {
+	case "hsl":
+		from, to = rgbtohsl(r1, g1, b1), rgbtohsl(r2, g2, b2)
+		if d := to[0] - from[0]; d > 180 {
+			to[0] -= 360
+		} else if d < -180 {
+			to[0] += 360
+		}
+		back = hsltorgb
+	case "oklab":
+		from, to = rgbtooklab(r1, g1, b1), rgbtooklab(r2, g2, b2)
+		back = oklabtorgb
+	default:
+		from = [3]float64{float64(r1), float64(g1), float64(b1)}
+		to = [3]float64{float64(r2), float64(g2), float64(b2)}
+		back = func(c [3]float64) (r, g, b uint8) { return clampcolor(c[0]), clampcolor(c[1]), clampcolor(c[2]) }
+	}
+	ramp := make([]string, steps)
+	for i := range ramp {
+		t := 0.0
+		if steps > 1 {
+			t = float64(i) / float64(steps-1)
+		}
+		var c [3]float64
+		for j := range c {
+			c[j] = from[j] + (to[j]-from[j])*t
+		}
+		r, g, b := back(c)
+		ramp[i] = fmt.Sprintf("#%02x%02x%02x", r, g, b)
+	}
+	return ramp
+}
+
+// RampStops returns evenly spaced gradient stops for the colors of RampBetweenIn,
+// for use with LinearGradient and RadialGradient
+func RampStops(c1, c2 string, steps int, space string) []Offcolor {
+	ramp := RampBetweenIn(c1, c2, steps, space)
+	stops := make([]Offcolor, len(ramp))
+	for i, c := range ramp {
+		offset := 0
+		if len(ramp) > 1 {
+			offset = i * 100 / (len(ramp) - 1)
+		}
+		stops[i] = Offcolor{Offset: uint8(offset), Color: c, Opacity: 1}
+	}
+	return stops
+}
+
+// clampcolor rounds a color component to the range 0-255
+func clampcolor(v float64) uint8 { return uint8(math.Round(math.Max(0, math.Min(255, v)))) }
+
+// rgbtohsl converts a color to hue (in degrees), saturation and lightness (0-1)
+func rgbtohsl(r, g, b uint8) [3]float64 {
+	rf, gf, bf := float64(r)/255, float64(g)/255, float64(b)/255
+	max, min := math.Max(rf, math.Max(gf, bf)), math.Min(rf, math.Min(gf, bf))
+	l := (max + min) / 2
+	if max == min {
+		return [3]float64{0, 0, l}
+	}
+	d := max - min
+	s := d / (1 - math.Abs(2*l-1))
+	var h float64
+	switch max {
+	case rf:
+		h = math.Mod((gf-bf)/d+6, 6)
+	case gf:
+		h = (bf-rf)/d + 2
+	default:
+		h = (rf-gf)/d + 4
+	}
+	return [3]float64{h * 60, s, l}
+}
+
+// hsltorgb converts hue (in degrees), saturation and lightness to a color
+func hsltorgb(c [3]float64) (r, g, b uint8) {
+	h, s, l := math.Mod(math.Mod(c[0], 360)+360, 360), c[1], c[2]
+	k := (1 - math.Abs(2*l-1)) * s
+	x := k * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	var rf, gf, bf float64
+	switch {
+	case h < 60:
+		rf, gf = k, x
+	case h < 120:
+		rf, gf = x, k
+	case h < 180:
+		gf, bf = k, x
+	case h < 240:
+		gf, bf = x, k
+	case h < 300:
+		rf, bf = x, k
+	default:
+		rf, bf = k, x
+	}
+	m := l - k/2
+	return clampcolor((rf + m) * 255), clampcolor((gf + m) * 255), clampcolor((bf + m) * 255)
+}
+
+// rgbtooklab converts a color to the OkLab L, a and b coordinates
+func rgbtooklab(r, g, b uint8) [3]float64 {
+	rl, gl, bl := srgbtolinear(r), srgbtolinear(g), srgbtolinear(b)
+	l := math.Cbrt(0.4122214708*rl + 0.5363325363*gl + 0.0514459929*bl)
+	m := math.Cbrt(0.2119034982*rl + 0.6806995451*gl + 0.1073969566*bl)
+	s := math.Cbrt(0.0883024619*rl + 0.2817188376*gl + 0.6299787005*bl)
+	return [3]float64{
+		0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+// oklabtorgb converts OkLab L, a and b coordinates to a color
+func oklabtorgb(c [3]float64) (r, g, b uint8) {
+	l := c[0] + 0.3963377774*c[1] + 0.2158037573*c[2]
+	m := c[0] - 0.1055613458*c[1] - 0.0638541728*c[2]
+	s := c[0] - 0.0894841775*c[1] - 1.2914855480*c[2]
+	l, m, s = l*l*l, m*m*m, s*s*s
+	return lineartosrgb(4.0767416621*l - 3.3077115913*m + 0.2309699292*s),
+		lineartosrgb(-1.2684380046*l + 2.6097574011*m - 0.3413193965*s),
+		lineartosrgb(-0.0041960863*l - 0.7034186147*m + 1.7076147010*s)
+}
+
+// srgbtolinear converts an sRGB component to linear light, in the range 0-1
+func srgbtolinear(c uint8) float64 {
+	v := float64(c) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// lineartosrgb converts linear light to an sRGB component
+func lineartosrgb(v float64) uint8 {
+	if v <= 0.0031308 {
+		return clampcolor(v * 12.92 * 255)
+	}
+	return clampcolor((1.055*math.Pow(v, 1/2.4) - 0.055) * 255)
+}
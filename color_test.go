@@ -0,0 +1,217 @@
+package svg
+
+import (
+	"math"
+	"testing"
+)
+
+// near determines if the components of a and b differ by no more than tolerance
+func near(a, b [3]float64, tolerance float64) bool {
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+func TestOkLab(t *testing.T) {
+	// reference values from Björn Ottosson's OkLab definition, as used by CSS Color Level 4
+	for _, c := range []struct {
+		color string
+		lab   [3]float64
+	}{
+		{"#ffffff", [3]float64{1, 0, 0}},
+		{"#000000", [3]float64{0, 0, 0}},
+		{"#ff0000", [3]float64{0.627955, 0.224863, 0.125846}},
+		{"#00ff00", [3]float64{0.866440, -0.233888, 0.179498}},
+		{"#0000ff", [3]float64{0.452014, -0.032457, -0.311528}},
+		{"#808080", [3]float64{0.599871, 0, 0}},
+	} {
+		r, g, b, _ := parsecolor(c.color)
+		lab := rgbtooklab(r, g, b)
+		if !near(lab, c.lab, 1e-4) {
+			t.Errorf("OkLab of %s = %.6f, want %.6f", c.color, lab, c.lab)
+		}
+		if rr, gg, bb := oklabtorgb(lab); rr != r || gg != g || bb != b {
+			t.Errorf("%s converted to OkLab and back is #%02x%02x%02x", c.color, rr, gg, bb)
+		}
+	}
+}
+
+func TestHSL(t *testing.T) {
+	// reference values from the CSS Color Level 4 named colors
+	for _, c := range []struct {
+		color string
+		hsl   [3]float64
+	}{
+		{"red", [3]float64{0, 1, 0.5}},
+		{"lime", [3]float64{120, 1, 0.5}},
+		{"blue", [3]float64{240, 1, 0.5}},
+		{"white", [3]float64{0, 0, 1}},
+		{"teal", [3]float64{180, 1, 0.250980}},
+		{"orange", [3]float64{38.823529, 1, 0.5}},
+		{"rebeccapurple", [3]float64{270, 0.5, 0.4}},
+		{"crimson", [3]float64{348, 0.833333, 0.470588}},
+	} {
+		r, g, b, _ := parsecolor(c.color)
+		hsl := rgbtohsl(r, g, b)
+		if !near(hsl, c.hsl, 1e-4) {
+			t.Errorf("HSL of %s = %.6f, want %.6f", c.color, hsl, c.hsl)
+		}
+		if rr, gg, bb := hsltorgb(hsl); rr != r || gg != g || bb != b {
+			t.Errorf("%s converted to HSL and back is #%02x%02x%02x", c.color, rr, gg, bb)
+		}
+	}
+}
+
+func TestColorRoundTrip(t *testing.T) {
+	for r := 0; r < 256; r += 15 {
+		for g := 0; g < 256; g += 15 {
+			for b := 0; b < 256; b += 15 {
+				c := [3]uint8{uint8(r), uint8(g), uint8(b)}
+				if rr, gg, bb := oklabtorgb(rgbtooklab(c[0], c[1], c[2])); [3]uint8{rr, gg, bb} != c {
+					t.Errorf("%v converted to OkLab and back is %v", c, [3]uint8{rr, gg, bb})
+				}
+				if rr, gg, bb := hsltorgb(rgbtohsl(c[0], c[1], c[2])); [3]uint8{rr, gg, bb} != c {
+					t.Errorf("%v converted to HSL and back is %v", c, [3]uint8{rr, gg, bb})
+				}
+			}
+		}
+	}
+}
+
+func TestRampBetween(t *testing.T) {
+	for _, c := range []struct {
+		c1, c2 string
+		steps  int
+		space  string
+		want   []string
+	}{
+		{"red", "blue", 3, "rgb", []string{"#ff0000", "#800080", "#0000ff"}},
+		{"#000", "#fff", 5, "rgb", []string{"#000000", "#404040", "#808080", "#bfbfbf", "#ffffff"}},
+		{"rgb(255,0,0)", "rgb(0,0,255)", 1, "rgb", []string{"#ff0000"}},
+		{"red", "blue", 3, "hsl", []string{"#ff0000", "#ff00ff", "#0000ff"}},
+		{"red", "lime", 3, "hsl", []string{"#ff0000", "#ffff00", "#00ff00"}},
+		{"red", "blue", 3, "bogus", []string{"#ff0000", "#800080", "#0000ff"}},
+		{"red", "notacolor", 3, "rgb", nil},
+		{"red", "blue", 0, "rgb", nil},
+	} {
+		got := RampBetweenIn(c.c1, c.c2, c.steps, c.space)
+		if len(got) != len(c.want) {
+			t.Errorf("RampBetweenIn(%s, %s, %d, %s) = %q, want %q", c.c1, c.c2, c.steps, c.space, got, c.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("RampBetweenIn(%s, %s, %d, %s) = %q, want %q", c.c1, c.c2, c.steps, c.space, got, c.want)
+				break
+			}
+		}
+	}
+	if got := RampBetween("red", "blue", 3); got[1] != "#800080" {
+		t.Errorf("RampBetween midpoint %s, want #800080", got[1])
+	}
+}
+
+func TestRampOkLab(t *testing.T) {
+	ramp := RampBetweenIn("red", "blue", 5, "oklab")
+	if len(ramp) != 5 || ramp[0] != "#ff0000" || ramp[4] != "#0000ff" {
+		t.Fatalf("ramp %q does not run from red to blue", ramp)
+	}
+	r1, g1, b1, _ := parsecolor("red")
+	r2, g2, b2, _ := parsecolor("blue")
+	from, to := rgbtooklab(r1, g1, b1), rgbtooklab(r2, g2, b2)
+	for i, c := range ramp {
+		r, g, b, _ := parsecolor(c)
+		var want [3]float64
+		for j := range want {
+			want[j] = from[j] + (to[j]-from[j])*float64(i)/4
+		}
+		// colors are rounded to 8 bits, so the steps are only near the interpolated coordinates
+		if lab := rgbtooklab(r, g, b); !near(lab, want, 0.01) {
+			t.Errorf("step %d: %s is OkLab %.4f, want %.4f", i, c, lab, want)
+		}
+	}
+	// the RGB midpoint of red and blue is darker than both; the OkLab midpoint is not
+	r, g, b, _ := parsecolor(RampBetween("red", "blue", 3)[1])
+	rm, gm, bm, _ := parsecolor(ramp[2])
+	if l, rgbl := rgbtooklab(rm, gm, bm)[0], rgbtooklab(r, g, b)[0]; l <= rgbl || l < to[0] {
+		t.Errorf("OkLab midpoint %s has lightness %.3f, RGB midpoint %.3f", ramp[2], l, rgbl)
+	}
+}
+
+func TestRampHueShortPath(t *testing.T) {
+	c350, c10 := "#ff002b", "#ff2b00" // hsl(350, 100%, 50%) and hsl(10, 100%, 50%)
+	for _, pair := range [][2]string{{c350, c10}, {c10, c350}} {
+		ramp := RampBetweenIn(pair[0], pair[1], 5, "hsl")
+		if len(ramp) != 5 || ramp[2] != "#ff0000" {
+			t.Errorf("%s to %s: ramp %q, want red in the middle", pair[0], pair[1], ramp)
+		}
+		for _, c := range ramp {
+			r, g, b, _ := parsecolor(c)
+			if h := rgbtohsl(r, g, b)[0]; h > 10.5 && h < 349.5 {
+				t.Errorf("%s to %s: %s has hue %.1f, off the short path", pair[0], pair[1], c, h)
+			}
+		}
+	}
+}
+
+func TestValidColor(t *testing.T) {
+	for _, c := range []struct {
+		s     string
+		valid bool
+	}{
+		{"red", true},
+		{"DarkRed", true},
+		{" rebeccapurple ", true},
+		{"grey", true},
+		{"gray", true},
+		{"none", true},
+		{"currentColor", true},
+		{"transparent", true},
+		{"drakred", false},
+		{"", false},
+		{"red blue", false},
+
+		{"#f00", true},
+		{"#F00a", true},
+		{"#ff0000", true},
+		{"#ff000080", true},
+		{"#ff", false},
+		{"#ff00000", false},
+		{"#gg0000", false},
+		{"#-f0000", false},
+		{"ff0000", false},
+
+		{"rgb(255,0,0)", true},
+		{"rgb(255, 0, 0)", true},
+		{"rgb(100%, 0%, 50%)", true},
+		{"rgba(255,0,0,0.5)", true},
+		{"rgb(255 0 0 / 50%)", true},
+		{"rgb(255,0)", false},
+		{"rgb(255,0,0,0,0)", false},
+		{"rgb(red,0,0)", false},
+		{"rgb(255,0,0", false},
+		{"rgb(255 0 0 /)", false},
+
+		{"hsl(120, 100%, 50%)", true},
+		{"hsl(120deg 100% 50%)", true},
+		{"hsla(120, 100%, 50%, 0.3)", true},
+		{"hsl(120, 100, 50)", false},
+		{"hsl(blue, 100%, 50%)", false},
+		{"hsl(120, 100%)", false},
+
+		{"cmyk(0,0,0,0)", false},
+		{"url(#paint)", false},
+	} {
+		if got := ValidColor(c.s); got != c.valid {
+			t.Errorf("ValidColor(%q) = %v, want %v", c.s, got, c.valid)
+		}
+	}
+	for name, hex := range colornames {
+		if !ValidColor(name) || !ValidColor(hex) {
+			t.Errorf("named color %s (%s) not valid", name, hex)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package svg
+
+import "strings"
+
+// RequiredExtensions returns the requiredExtensions attribute for the extension URIs, separated
+// by spaces, to be passed as an optional attribute; or the empty string, for none
+func RequiredExtensions(uris ...string) string { return condattr("requiredExtensions", " ", uris) }
+
+// RequiredFeatures returns the requiredFeatures attribute for the feature strings, separated
+// by spaces, to be passed as an optional attribute; or the empty string, for none
+func RequiredFeatures(features ...string) string { return condattr("requiredFeatures", " ", features) }
+
+// SystemLanguage returns the systemLanguage attribute for the language tags, such as "en" or "de-AT",
+// separated by commas, to be passed as an optional attribute; or the empty string, for none
+func SystemLanguage(langs ...string) string { return condattr("systemLanguage", ",", langs) }
+
+// GroupIf begins a group rendered only when the conditional attributes cond, such as made by
+// SystemLanguage, hold, with optional style. End with Gend.
+func (svg *SVG) GroupIf(cond string, s ...string) {
+	svg.Group(append([]string{cond}, s...)...)
+}
+
+// condattr returns the conditional processing attribute name, for the values
+// trimmed of spaces, joined by sep, omitting empty values
+func condattr(name, sep string, values []string) string {
+	v := make([]string, 0, len(values))
+	for _, s := range values {
+		if s = strings.TrimSpace(s); s != "" {
+			v = append(v, s)
+		}
+	}
+	if len(v) == 0 {
+		return ""
+	}
+	return Raw(name + `="` + attrescape(strings.Join(v, sep)) + `"`)
+}
@@ -0,0 +1,116 @@
+package svg
+
+import (
+	"strconv"
+	"strings"
+)
+
+// HighContrast specifies the high contrast rendering mode.
+// Fill and stroke colors are rewritten by Map; if Map is nil, colors with a luminance
+// below 0.5 become black, and the rest white. Shapes with an opacity below MinOpacity are
+// considered decorative and are not drawn, and stroke widths below MinStroke are raised to it.
+type HighContrast struct {
+	Map        func(color string) string
+	MinOpacity float64
+	MinStroke  float64
+}
+
+// SetHighContrast renders subsequent elements in the high contrast mode hc;
+// a nil hc returns to normal rendering.
+func (svg *SVG) SetHighContrast(hc *HighContrast) { svg.contrast = hc }
+
+// endstyle applies the rendering mode to the style and attributes in s,
+// before completing the element with endtag
+func (svg *SVG) endstyle(s []string, endtag string) string {
+	svg.checkchars(s...)
+	return endstyle(svg.restyle(s), endtag)
+}
+
+// restyle rewrites the style and attributes in s according to the high contrast mode
+func (svg *SVG) restyle(s []string) []string {
+	hc := svg.contrast
+	if hc == nil || len(s) == 0 {
+		return s
+	}
+	r := make([]string, len(s))
+	for i, v := range s {
+		if strings.HasPrefix(v, rawmark) {
+			r[i] = v
+			continue
+		}
+		if strings.Index(v, "=") > 0 {
+			if a, ok := parseattrs(v); ok {
+				for j, d := range a {
+					if value, ok := hc.property(d[0], d[1]); ok {
+						a[j][1] = value
+					}
+				}
+				v = formatattrs(a)
+			}
+			r[i] = v
+			continue
+		}
+		decl := parsestyle(v)
+		for j, d := range decl {
+			if value, ok := hc.property(d[0], d[1]); ok {
+				decl[j][1] = value
+			}
+		}
+		r[i] = formatstyle(decl)
+	}
+	return r
+}
+
+// decorative determines if, in high contrast mode, a shape with the style
+// and attributes in s is decorative and should not be drawn
+func (svg *SVG) decorative(s []string) bool {
+	hc := svg.contrast
+	if hc == nil || hc.MinOpacity <= 0 {
+		return false
+	}
+	for _, v := range s {
+		decl, ok := parseattrs(strings.TrimPrefix(v, rawmark))
+		if !ok {
+			decl = parsestyle(v)
+		}
+		for _, d := range decl {
+			if d[0] != "opacity" {
+				continue
+			}
+			if o, err := strconv.ParseFloat(d[1], 64); err == nil && o < hc.MinOpacity {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// property returns the high contrast value of a style property, and whether it was changed
+func (hc *HighContrast) property(name, value string) (string, bool) {
+	switch name {
+	case "fill", "stroke":
+		if hc.Map != nil {
+			return hc.Map(value), true
+		}
+		r, g, b, ok := parsecolor(value)
+		if !ok {
+			return value, false
+		}
+		if luminance(r, g, b) < 0.5 {
+			return "black", true
+		}
+		return "white", true
+	case "stroke-width":
+		w, err := strconv.ParseFloat(strings.TrimSuffix(value, "px"), 64)
+		if err != nil || w >= hc.MinStroke {
+			return value, false
+		}
+		return strconv.FormatFloat(hc.MinStroke, 'g', -1, 64), true
+	}
+	return value, false
+}
+
+// luminance returns the luminance of a color, in the range 0-1
+func luminance(r, g, b uint8) float64 {
+	return (0.2126*float64(r) + 0.7152*float64(g) + 0.0722*float64(b)) / 255
+}
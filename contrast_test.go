@@ -0,0 +1,68 @@
+package svg
+
+import (
+	"strings"
+	"testing"
+)
+
+// chart draws a small colored chart
+func chart(canvas *SVG) {
+	canvas.Rect(0, 0, 100, 100, "fill:navy")
+	canvas.Rect(10, 50, 20, 40, "fill:#ff0;stroke:darkred;stroke-width:0.5")
+	canvas.Rect(40, 30, 20, 60, `fill="orange" stroke="rgb(20,20,20)" stroke-width="3"`)
+	canvas.Circle(50, 50, 40, "fill:lime;opacity:0.1")
+	canvas.Line(0, 90, 100, 90, "stroke:#336699;stroke-width:1px")
+	canvas.Text(50, 20, "Sales", "fill:gray;font-size:8px")
+}
+
+func TestHighContrast(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		hc   *HighContrast
+		want []string
+	}{
+		{"normal", nil, []string{
+			`<rect x="0" y="0" width="100" height="100" style="fill:navy"/>`,
+			`<rect x="10" y="50" width="20" height="40" style="fill:#ff0;stroke:darkred;stroke-width:0.5"/>`,
+			`<rect x="40" y="30" width="20" height="60" fill="orange" stroke="rgb(20,20,20)" stroke-width="3"/>`,
+			`<circle cx="50" cy="50" r="40" style="fill:lime;opacity:0.1"/>`,
+			`<line x1="0" y1="90" x2="100" y2="90" style="stroke:#336699;stroke-width:1px"/>`,
+			`<text x="50" y="20" style="fill:gray;font-size:8px">Sales</text>`,
+		}},
+		{"high contrast", &HighContrast{MinOpacity: 0.2, MinStroke: 2}, []string{
+			`<rect x="0" y="0" width="100" height="100" style="fill:black"/>`,
+			`<rect x="10" y="50" width="20" height="40" style="fill:white;stroke:black;stroke-width:2"/>`,
+			`<rect x="40" y="30" width="20" height="60" fill="white" stroke="black" stroke-width="3"/>`,
+			`<line x1="0" y1="90" x2="100" y2="90" style="stroke:black;stroke-width:2"/>`,
+			`<text x="50" y="20" style="fill:white;font-size:8px">Sales</text>`,
+		}},
+		{"mapped", &HighContrast{Map: func(string) string { return "currentColor" }}, []string{
+			`<rect x="0" y="0" width="100" height="100" style="fill:currentColor"/>`,
+			`<rect x="10" y="50" width="20" height="40" style="fill:currentColor;stroke:currentColor;stroke-width:0.5"/>`,
+			`<rect x="40" y="30" width="20" height="60" fill="currentColor" stroke="currentColor" stroke-width="3"/>`,
+			`<circle cx="50" cy="50" r="40" style="fill:currentColor;opacity:0.1"/>`,
+			`<line x1="0" y1="90" x2="100" y2="90" style="stroke:currentColor;stroke-width:1px"/>`,
+			`<text x="50" y="20" style="fill:currentColor;font-size:8px">Sales</text>`,
+		}},
+	} {
+		doc := render(t, func(canvas *SVG) {
+			canvas.SetHighContrast(c.hc)
+			chart(canvas)
+		})
+		body := doc[strings.Index(doc, ">\n<rect")+2 : strings.Index(doc, "</svg>")]
+		if got := strings.TrimSpace(body); got != strings.Join(c.want, "\n") {
+			t.Errorf("%s: drew\n%s\nwant\n%s", c.name, got, strings.Join(c.want, "\n"))
+		}
+		wellformed(t, doc)
+	}
+
+	doc := render(t, func(canvas *SVG) {
+		canvas.SetHighContrast(&HighContrast{MinOpacity: 0.2, MinStroke: 2})
+		canvas.Rect(0, 0, 10, 10, "fill:navy")
+		canvas.SetHighContrast(nil)
+		canvas.Rect(0, 0, 10, 10, "fill:navy")
+	})
+	if !strings.Contains(doc, `style="fill:black"/>`+"\n"+`<rect x="0" y="0" width="10" height="10" style="fill:navy"/>`) {
+		t.Errorf("high contrast not turned off in\n%s", doc)
+	}
+}
@@ -0,0 +1,52 @@
+package svg
+
+import "fmt"
+
+// description is a long description of a shape, written at the end of the document
+type description struct {
+	id, text string
+}
+
+// Described emits a shape with el, passing it attributes labeling the shape with shortLabel,
+// and referring to longDesc, which is collected into a hidden group written at End.
+// Either label may be empty, in which case its attribute is omitted.
+func (svg *SVG) Described(el func(extraAttrs ...string), shortLabel, longDesc string) {
+	var attrs []string
+	if shortLabel != "" {
+		attrs = append(attrs, fmt.Sprintf(`aria-label="%s"`, xmlescape(shortLabel)))
+	}
+	if longDesc != "" {
+		unlock := svg.lock()
+		id := svg.uid("desc")
+		svg.descriptions = append(svg.descriptions, description{id: id, text: longDesc})
+		unlock()
+		attrs = append(attrs, fmt.Sprintf(`aria-describedby="%s"`, id))
+	}
+	el(attrs...)
+}
+
+// DescribedCircle centered at x,y, with radius r, labeled with shortLabel and described by longDesc,
+// with optional style.
+func (svg *SVG) DescribedCircle(x, y, r int, shortLabel, longDesc string, s ...string) {
+	svg.Described(func(extra ...string) {
+		svg.Circle(x, y, r, append(append([]string(nil), s...), extra...)...)
+	}, shortLabel, longDesc)
+}
+
+// describe writes the collected descriptions, in a hidden group
+func (svg *SVG) describe() {
+	if len(svg.descriptions) == 0 {
+		return
+	}
+	svg.count("g")
+	svg.println(`<g display="none">`)
+	for _, d := range svg.descriptions {
+		svg.count("desc")
+		svg.printf(`<desc %s>`, svg.idattr(d.id))
+		svg.escape(d.text)
+		svg.println(`</desc>`)
+		svg.textitem("desc", d.text, 0, 0, false)
+	}
+	svg.println(`</g>`)
+	svg.descriptions = nil
+}
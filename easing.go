@@ -0,0 +1,44 @@
+package svg
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrInvalidEasing is returned for cubic Bézier easings with a control point x outside 0-1
+var ErrInvalidEasing = errors.New("svg: easing control point outside 0-1")
+
+// Ease returns the keySplines value of the CSS ease timing function
+func Ease() string { return "0.25 0.1 0.25 1" }
+
+// EaseIn returns the keySplines value of the CSS ease-in timing function
+func EaseIn() string { return "0.42 0 1 1" }
+
+// EaseOut returns the keySplines value of the CSS ease-out timing function
+func EaseOut() string { return "0 0 0.58 1" }
+
+// EaseInOut returns the keySplines value of the CSS ease-in-out timing function
+func EaseInOut() string { return "0.42 0 0.58 1" }
+
+// CubicBezier returns the keySplines value of a cubic Bézier easing with the control points
+// (x1,y1) and (x2,y2), like the CSS cubic-bezier timing function. The x coordinates must be within 0-1.
+func CubicBezier(x1, y1, x2, y2 float64) (string, error) {
+	if !(x1 >= 0 && x1 <= 1 && x2 >= 0 && x2 <= 1) {
+		return "", fmt.Errorf("%w: cubic-bezier(%g, %g, %g, %g)", ErrInvalidEasing, x1, y1, x2, y2)
+	}
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	return f(x1) + " " + f(y1) + " " + f(x2) + " " + f(y2), nil
+}
+
+// AnimateEased animates the specified link, using the specified attribute, from from to to,
+// eased by the keySplines value easing (for example EaseInOut()), and repeats as specified.
+func (svg *SVG) AnimateEased(link, attr string, from, to float64, duration float64, easing string, repeat int, s ...string) {
+	defer svg.lock()()
+	if svg.blocked("animate") || svg.unsafelink(link) {
+		return
+	}
+	svg.count("animate")
+	svg.printf(`<animate %s attributeName="%s" from="%g" to="%g" dur="%gs" %s calcMode="spline" keyTimes="0;1" keySplines="%s" %s`,
+		svg.href(link), attr, from, to, duration, repeatattr(Repeat{Count: float64(repeat)}), attrescape(easing), svg.endstyle(s, emptyclose))
+}
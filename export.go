@@ -0,0 +1,123 @@
+package svg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNoDocument is returned when exporting, or making Markup of, a canvas without a complete svg element
+var ErrNoDocument = errors.New("svg: no complete document")
+
+// ExportRegion returns a standalone document showing the region at x,y with dimension w,h of
+// the document on a canvas made with NewBuffer. The document's contents are placed in a new root
+// element of the region's size, whose viewBox is the region, keeping the namespaces of the original.
+// Its top level definitions are hoisted to the start of the new root, ahead of the other contents,
+// which, if clip is set, are also clipped to the region.
+func (svg *SVG) ExportRegion(x, y, w, h int, clip bool) ([]byte, error) {
+	defer svg.lock()()
+	if !svg.Capabilities().Buffered {
+		return nil, ErrRequiresBuffer
+	}
+	svg.flush()
+	ns, body, ok := splitroot(svg.doc.Bytes())
+	if !ok {
+		return nil, ErrNoDocument
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `%s width="%d" height="%d" viewBox="%d %d %d %d"`, svg.top(), w, h, x, y, w, h)
+	for _, a := range ns {
+		fmt.Fprintf(&b, "\n     %s", formatattrs([][2]string{a}))
+	}
+	b.WriteString(">\n")
+	defs, body := hoistdefs(body)
+	b.Write(defs)
+	if !clip {
+		b.Write(body)
+		b.WriteString("</svg>\n")
+		return b.Bytes(), nil
+	}
+	id := svg.uid("export")
+	fmt.Fprintf(&b, "<clipPath id=\"%s\"><rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\"/></clipPath>\n", id, x, y, w, h)
+	fmt.Fprintf(&b, "<g clip-path=\"url(#%s)\">\n", id)
+	b.Write(body)
+	b.WriteString("</g>\n</svg>\n")
+	return b.Bytes(), nil
+}
+
+// splitroot divides a document into the namespace declarations of its root element, and its contents
+func splitroot(doc []byte) (ns [][2]string, body []byte, ok bool) {
+	start := bytes.Index(doc, []byte("<svg"))
+	end := bytes.LastIndex(doc, []byte("</svg>"))
+	if start < 0 || end < start {
+		return nil, nil, false
+	}
+	var quote byte
+	open := -1
+	for i := start; i < end && open < 0; i++ {
+		switch c := doc[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			open = i
+		}
+	}
+	if open < 0 {
+		return nil, nil, false
+	}
+	attrs, _ := parseattrs(strings.TrimSuffix(string(doc[start+len("<svg"):open]), "/"))
+	for _, a := range attrs {
+		if a[0] == "xmlns" || strings.HasPrefix(a[0], "xmlns:") {
+			ns = append(ns, a)
+		}
+	}
+	return ns, bytes.TrimLeft(doc[open+1:end], "\n"), true
+}
+
+// hoistdefs divides the contents of a document into its top level defs elements, and the rest.
+// Contents that cannot be parsed are left whole.
+func hoistdefs(body []byte) (defs, rest []byte) {
+	var hoisted, others bytes.Buffer
+	d := xml.NewDecoder(bytes.NewReader(body))
+	depth := 0
+	var from, last, prev int64 = -1, 0, 0
+	for {
+		t, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, body
+		}
+		off := d.InputOffset()
+		switch e := t.(type) {
+		case xml.StartElement:
+			if depth == 0 && e.Name.Space == "" && e.Name.Local == "defs" {
+				from = prev
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+			if depth == 0 && from >= 0 {
+				others.Write(body[last:from])
+				hoisted.Write(body[from:off])
+				hoisted.WriteByte('\n')
+				last = off
+				if last < int64(len(body)) && body[last] == '\n' {
+					last++
+				}
+				from = -1
+			}
+		}
+		prev = off
+	}
+	others.Write(body[last:])
+	return hoisted.Bytes(), others.Bytes()
+}
@@ -0,0 +1,126 @@
+package svg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// exported draws a document referring to its definitions from within groups, and returns the canvas
+func exported(t *testing.T) *SVG {
+	t.Helper()
+	canvas := NewBuffer()
+	canvas.Start(200, 100)
+	canvas.Rect(0, 0, 200, 100, "fill:url(#sky)")
+	canvas.Def()
+	canvas.LinearGradient("sky", 0, 0, 0, 100, []Offcolor{{0, "blue", 1}, {100, "white", 1}})
+	canvas.Symbol("dot")
+	canvas.Circle(5, 5, 5)
+	canvas.SymbolEnd()
+	canvas.DefEnd()
+	canvas.Group(`id="panel"`)
+	canvas.Use(110, 10, "#dot")
+	canvas.ClipRect("inner", 100, 0, 100, 100)
+	canvas.Rect(100, 0, 100, 100, "clip-path:url(#inner)")
+	canvas.Gend()
+	canvas.End()
+	if err := canvas.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	return canvas
+}
+
+// references returns the ids referred to in doc, and the ids it defines, failing the test
+// if doc is not well formed
+func references(t *testing.T, doc []byte) (refs []string, ids map[string]bool) {
+	t.Helper()
+	ids = make(map[string]bool)
+	d := xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("%v in\n%s", err, doc)
+		}
+		if e, ok := tok.(xml.StartElement); ok {
+			for _, a := range e.Attr {
+				if a.Name.Local == "id" {
+					ids[a.Value] = true
+				}
+			}
+		}
+	}
+	for _, m := range regexp.MustCompile(`(?:url\(#|href="#)([^)"]+)`).FindAllSubmatch(doc, -1) {
+		refs = append(refs, string(m[1]))
+	}
+	return refs, ids
+}
+
+func TestExportRegion(t *testing.T) {
+	canvas := exported(t)
+	for _, clip := range []bool{false, true} {
+		out, err := canvas.ExportRegion(100, 0, 100, 50, clip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc := string(out)
+		if want := `<svg width="100" height="50" viewBox="100 0 100 50"`; !strings.Contains(doc, want) {
+			t.Errorf("clip %v: %s\nmissing from\n%s", clip, want, doc)
+		}
+		if want := `xmlns:xlink="http://www.w3.org/1999/xlink"`; !strings.Contains(doc, want) {
+			t.Errorf("clip %v: %s\nmissing from\n%s", clip, want, doc)
+		}
+		hoisted := strings.Index(doc, "<defs>\n<linearGradient")
+		content := strings.Index(doc, `<rect x="0" y="0" width="200" height="100"`)
+		if hoisted < 0 || content < hoisted {
+			t.Errorf("clip %v: definitions not hoisted ahead of the contents in\n%s", clip, doc)
+		}
+		if n := strings.Count(doc, "<linearGradient"); n != 1 {
+			t.Errorf("clip %v: %d gradients, want 1, in\n%s", clip, n, doc)
+		}
+		if !strings.Contains(doc, `<g id="panel">`+"\n"+`<use x="110" y="10" xlink:href="#dot"/>`+"\n<defs>\n<clipPath") {
+			t.Errorf("clip %v: nested definitions moved in\n%s", clip, doc)
+		}
+		clipped := regexp.MustCompile(`<clipPath id="(export-\d+)"><rect x="100" y="0" width="100" height="50"/></clipPath>` + "\n" +
+			`<g clip-path="url\(#(export-\d+)\)">` + "\n")
+		m := clipped.FindStringSubmatch(doc)
+		switch {
+		case clip && (m == nil || m[1] != m[2]):
+			t.Errorf("clip %v: clip to the region missing from\n%s", clip, doc)
+		case clip && strings.Index(doc, m[0]) < hoisted:
+			t.Errorf("clip %v: definitions clipped in\n%s", clip, doc)
+		case !clip && strings.Contains(doc, "<clipPath id=\"export"):
+			t.Errorf("clip %v: clipped\n%s", clip, doc)
+		}
+		refs, ids := references(t, out)
+		want := []string{"sky", "dot", "inner"}
+		if clip && m != nil {
+			want = append([]string{m[1]}, want...)
+		}
+		if strings.Join(refs, " ") != strings.Join(want, " ") {
+			t.Errorf("clip %v: references %q, want %q", clip, refs, want)
+		}
+		for _, r := range refs {
+			if !ids[r] {
+				t.Errorf("clip %v: reference to #%s does not resolve in\n%s", clip, r, doc)
+			}
+		}
+	}
+}
+
+func TestExportRegionRequiresDocument(t *testing.T) {
+	var b strings.Builder
+	if _, err := New(&b).ExportRegion(0, 0, 10, 10, false); err != ErrRequiresBuffer {
+		t.Errorf("New: error = %v, want ErrRequiresBuffer", err)
+	}
+	canvas := NewBuffer()
+	canvas.Start(100, 100)
+	if _, err := canvas.ExportRegion(0, 0, 10, 10, false); err != ErrNoDocument {
+		t.Errorf("unfinished document: error = %v, want ErrNoDocument", err)
+	}
+}
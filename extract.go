@@ -0,0 +1,75 @@
+package svg
+
+import "strings"
+
+// TextItem is a run of text emitted on a canvas: its content, the element containing it
+// ("text", "tspan", "textPath", "title" or "desc"), where it was placed (zero for elements without
+// a position), and the ids of the groups enclosing it, outermost first
+type TextItem struct {
+	Text       string
+	Element    string
+	X, Y       int
+	Groups     []string
+	Decorative bool
+}
+
+// decorativeattr marks text as decorative
+const decorativeattr = `aria-hidden="true"`
+
+// Decorative returns the attribute marking text as decorative, hiding it from assistive technology,
+// and from ExtractText when decorative text is skipped
+func Decorative() string { return decorativeattr }
+
+// ExtractText returns the text emitted on a canvas made with NewBuffer, in document order,
+// skipping text marked with Decorative if skipDecorative is set. Other canvases return nil.
+func (svg *SVG) ExtractText(skipDecorative bool) []TextItem {
+	defer svg.lock()()
+	var items []TextItem
+	for _, t := range svg.texts {
+		if skipDecorative && t.Decorative {
+			continue
+		}
+		items = append(items, t)
+	}
+	return items
+}
+
+// textitem records a run of text, on canvases made with NewBuffer
+func (svg *SVG) textitem(element, t string, x, y int, decorative bool) {
+	if !svg.Capabilities().Buffered || t == "" {
+		return
+	}
+	item := TextItem{Text: t, Element: element, X: x, Y: y, Decorative: decorative}
+	for i, tag := range svg.open {
+		if tag == "g" && svg.openids[i] != "" {
+			item.Groups = append(item.Groups, svg.openids[i])
+		}
+	}
+	svg.texts = append(svg.texts, item)
+}
+
+// isdecorative determines if the attributes in s mark text as decorative
+func isdecorative(s []string) bool {
+	for _, v := range s {
+		if strings.Contains(v, decorativeattr) {
+			return true
+		}
+	}
+	return false
+}
+
+// groupid returns the id among the attributes in s, if any
+func groupid(s []string) string {
+	for _, v := range s {
+		a, ok := parseattrs(strings.TrimPrefix(v, rawmark))
+		if !ok {
+			continue
+		}
+		for _, p := range a {
+			if p[0] == "id" {
+				return p[1]
+			}
+		}
+	}
+	return ""
+}
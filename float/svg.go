@@ -583,13 +583,20 @@ func (svg *SVG) FeColorMatrixSaturate(fs Filterspec, value float64, s ...string)
 		fsattr(fs), value, endstyle(s, emptyclose))
 }
 
-// FeColorMatrixLuminence specifies a color matrix filter primitive, with luminence values
+// FeColorMatrixLuminanceToAlpha specifies a color matrix filter primitive, converting luminance to alpha
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feColorMatrixElement
-func (svg *SVG) FeColorMatrixLuminence(fs Filterspec, s ...string) {
-	svg.printf(`<feColorMatrix %s type="luminenceToAlpha" %s`,
+func (svg *SVG) FeColorMatrixLuminanceToAlpha(fs Filterspec, s ...string) {
+	svg.printf(`<feColorMatrix %s type="luminanceToAlpha" %s`,
 		fsattr(fs), endstyle(s, emptyclose))
 }
 
+// FeColorMatrixLuminence specifies a color matrix filter primitive, converting luminance to alpha
+//
+// Deprecated: use FeColorMatrixLuminanceToAlpha.
+func (svg *SVG) FeColorMatrixLuminence(fs Filterspec, s ...string) {
+	svg.FeColorMatrixLuminanceToAlpha(fs, s...)
+}
+
 // FeComponentTransfer begins a feComponent filter element
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feComponentTransferElement
 func (svg *SVG) FeComponentTransfer() {
@@ -616,7 +623,7 @@ func (svg *SVG) FeComposite(fs Filterspec, operator string, k1, k2, k3, k4 int,
 }
 
 // FeConvolveMatrix specifies a feConvolveMatrix filter primitive
-// Standard referencd: http://www.w3.org/TR/SVG11/filters.html#feConvolveMatrixElement
+// Standard reference: http://www.w3.org/TR/SVG11/filters.html#feConvolveMatrixElement
 func (svg *SVG) FeConvolveMatrix(fs Filterspec, matrix [9]int, s ...string) {
 	svg.printf(`<feConvolveMatrix %s kernelMatrix="%d %d %d %d %d %d %d %d %d" %s`,
 		fsattr(fs),
@@ -626,8 +633,8 @@ func (svg *SVG) FeConvolveMatrix(fs Filterspec, matrix [9]int, s ...string) {
 }
 
 // FeDiffuseLighting specifies a diffuse lighting filter primitive,
-// a container for light source elements, end with DiffuseEnd()
-// Standard reference: http://www.w3.org/TR/SVG11/filters.html#feComponentTransferElement
+// a container for light source elements, end with FeDiffEnd()
+// Standard reference: http://www.w3.org/TR/SVG11/filters.html#feDiffuseLightingElement
 func (svg *SVG) FeDiffuseLighting(fs Filterspec, scale, constant float64, s ...string) {
 	svg.printf(`<feDiffuseLighting %s surfaceScale="%g" diffuseConstant="%g" %s`,
 		fsattr(fs), scale, constant, endstyle(s, `>`))
@@ -643,7 +650,7 @@ func (svg *SVG) FeDiffEnd() {
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feDisplacementMapElement
 func (svg *SVG) FeDisplacementMap(fs Filterspec, scale float64, xchannel, ychannel string, s ...string) {
 	svg.printf(`<feDisplacementMap %s scale="%g" xChannelSelector="%s" yChannelSelector="%s" %s`,
-		fsattr(fs), scale, imgchannel(xchannel), ychannel, endstyle(s, emptyclose))
+		fsattr(fs), scale, imgchannel(xchannel), imgchannel(ychannel), endstyle(s, emptyclose))
 }
 
 // FeDistantLight specifies a feDistantLight filter primitive
@@ -721,7 +728,7 @@ func (svg *SVG) FeMerge(nodes []string, s ...string) {
 	svg.println(`</feMerge>`)
 }
 
-// FeMorphology specifies a feMorphologyLight filter primitive
+// FeMorphology specifies a feMorphology filter primitive
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feMorphologyElement
 func (svg *SVG) FeMorphology(fs Filterspec, operator string, xradius, yradius float64, s ...string) {
 	switch operator {
@@ -741,7 +748,7 @@ func (svg *SVG) FeOffset(fs Filterspec, dx, dy int, s ...string) {
 		fsattr(fs), dx, dy, endstyle(s, emptyclose))
 }
 
-// FePointLight specifies a fePpointLight filter primitive
+// FePointLight specifies a fePointLight filter primitive
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#fePointLightElement
 func (svg *SVG) FePointLight(x, y, z float64, s ...string) {
 	svg.printf(`<fePointLight x="%g" y="%g" z="%g" %s`,
@@ -749,7 +756,7 @@ func (svg *SVG) FePointLight(x, y, z float64, s ...string) {
 }
 
 // FeSpecularLighting specifies a specular lighting filter primitive,
-// a container for light source elements, end with SpecularEnd()
+// a container for light source elements, end with FeSpecEnd()
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feSpecularLightingElement
 func (svg *SVG) FeSpecularLighting(fs Filterspec, scale, constant float64, exponent int, color string, s ...string) {
 	svg.printf(`<feSpecularLighting %s surfaceScale="%g" specularConstant="%g" specularExponent="%d" lighting-color="%s" %s`,
@@ -769,9 +776,13 @@ func (svg *SVG) FeSpotLight(fs Filterspec, x, y, z, px, py, pz float64, s ...str
 		fsattr(fs), x, y, z, px, py, pz, endstyle(s, emptyclose))
 }
 
-// FeTile specifies the tile utility filter primitive
+// FeTile specifies the tile utility filter primitive.
+// A non-empty in specifies the input, taking precedence over fs.In.
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feTileElement
 func (svg *SVG) FeTile(fs Filterspec, in string, s ...string) {
+	if in != "" {
+		fs.In = in
+	}
 	svg.printf(`<feTile %s %s`, fsattr(fs), endstyle(s, emptyclose))
 }
 
@@ -0,0 +1,76 @@
+package svg
+
+import (
+	"strings"
+	"testing"
+)
+
+// render returns the document drawn by draw on a 100x100 canvas
+func render(draw func(*SVG)) string {
+	var b strings.Builder
+	canvas := New(&b)
+	canvas.Start(100, 100)
+	draw(canvas)
+	canvas.End()
+	return b.String()
+}
+
+// element returns the element beginning with tag in doc, with its whitespace collapsed
+func element(doc, tag string) string {
+	i := strings.Index(doc, tag)
+	if i < 0 {
+		return ""
+	}
+	j := strings.Index(doc[i:], ">")
+	return strings.Join(strings.Fields(doc[i:i+j+1]), " ")
+}
+
+func TestFeColorMatrixLuminanceToAlpha(t *testing.T) {
+	fs := Filterspec{In: "SourceGraphic", Result: "alpha"}
+	for name, draw := range map[string]func(*SVG){
+		"FeColorMatrixLuminanceToAlpha": func(c *SVG) { c.FeColorMatrixLuminanceToAlpha(fs) },
+		"FeColorMatrixLuminence":        func(c *SVG) { c.FeColorMatrixLuminence(fs) },
+	} {
+		got := element(render(draw), "<feColorMatrix")
+		if want := `<feColorMatrix in="SourceGraphic" result="alpha" type="luminanceToAlpha" />`; got != want {
+			t.Errorf("%s = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestFeDisplacementMapChannels(t *testing.T) {
+	for _, c := range []struct{ x, y, want string }{
+		{"R", "G", `xChannelSelector="R" yChannelSelector="G"`},
+		{"b", "a", `xChannelSelector="B" yChannelSelector="A"`},
+		{"green", "blue", `xChannelSelector="G" yChannelSelector="B"`},
+		{"Alpha", "Red", `xChannelSelector="A" yChannelSelector="R"`},
+		{"bogus", "bogus", `xChannelSelector="R" yChannelSelector="R"`},
+	} {
+		got := element(render(func(canvas *SVG) { canvas.FeDisplacementMap(Filterspec{}, 10, c.x, c.y) }), "<feDisplacementMap")
+		if want := `<feDisplacementMap scale="10" ` + c.want + ` />`; got != want {
+			t.Errorf("FeDisplacementMap(%q, %q) = %s, want %s", c.x, c.y, got, want)
+		}
+	}
+}
+
+func TestFeTileChain(t *testing.T) {
+	for name, tile := range map[string]func(*SVG){
+		"in":         func(c *SVG) { c.FeTile(Filterspec{Result: "tiled"}, "blur") },
+		"Filterspec": func(c *SVG) { c.FeTile(Filterspec{In: "blur", Result: "tiled"}, "") },
+		"both":       func(c *SVG) { c.FeTile(Filterspec{In: "SourceGraphic", Result: "tiled"}, "blur") },
+	} {
+		doc := render(func(canvas *SVG) {
+			canvas.Filter("tile")
+			canvas.FeGaussianBlur(Filterspec{In: "SourceGraphic", Result: "blur"}, 2, 2)
+			tile(canvas)
+			canvas.Fend()
+		})
+		got := element(doc, "<feTile")
+		if want := `<feTile in="blur" result="tiled" />`; got != want {
+			t.Errorf("%s: %s, want %s", name, got, want)
+		}
+		if n := strings.Count(got, " in="); n != 1 {
+			t.Errorf("%s: in appears %d times in %s", name, n, got)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package svg
+
+const xhtmlns = "http://www.w3.org/1999/xhtml"
+
+// Foreign begins a foreignObject at x,y with dimension w,h, holding content of another namespace,
+// with optional style. End with ForeignEnd. The EmailSafe profile omits the foreignObject and its content.
+// Standard Reference: http://www.w3.org/TR/SVG11/extend.html#ForeignObjectElement
+func (svg *SVG) Foreign(x, y, w, h int, s ...string) {
+	defer svg.lock()()
+	if svg.hidden > 0 || svg.blocked("foreignObject") {
+		svg.hidden++
+		return
+	}
+	svg.count("foreignObject")
+	svg.push("foreignObject")
+	svg.printf("<foreignObject %s %s\n", dim(x, y, w, h), svg.endstyle(s, ">"))
+}
+
+// ForeignEnd ends a foreignObject
+func (svg *SVG) ForeignEnd() {
+	defer svg.lock()()
+	if svg.hidden > 0 {
+		svg.hidden--
+		return
+	}
+	svg.pop("foreignObject")
+	svg.println(`</foreignObject>`)
+}
+
+// ForeignHTML places the XHTML markup html at x,y with dimension w,h, within a div in the XHTML namespace.
+// The markup is trusted, and written as it is: it must be well formed XML. Use ForeignText for untrusted text.
+func (svg *SVG) ForeignHTML(x, y, w, h int, html string) {
+	svg.Foreign(x, y, w, h)
+	svg.xhtml(html)
+	svg.ForeignEnd()
+}
+
+// ForeignText places text at x,y with dimension w,h, escaped, within a div in the XHTML namespace,
+// where browsers wrap it to the width
+func (svg *SVG) ForeignText(x, y, w, h int, text string) {
+	svg.Foreign(x, y, w, h)
+	svg.xhtml(xmlescape(text))
+	svg.ForeignEnd()
+}
+
+// xhtml writes the div holding the markup of a foreignObject
+func (svg *SVG) xhtml(markup string) {
+	defer svg.lock()()
+	svg.checkchars(markup)
+	svg.printf("<div xmlns=\"%s\">%s</div>\n", xhtmlns, markup)
+}
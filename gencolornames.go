@@ -0,0 +1,69 @@
+//go:build ignore
+// +build ignore
+
+// gencolornames writes colornames.go, the table of CSS named colors, from the color list
+// in colortab/svgcolors.txt, adding rebeccapurple from CSS Color Level 4.
+// Run it with go generate.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// extra are the named colors missing from the SVG 1.1 list
+var extra = map[string]string{"rebeccapurple": "#663399"}
+
+func main() {
+	f, err := os.Open("colortab/svgcolors.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	colors := make(map[string]string)
+	for k, v := range extra {
+		colors[k] = v
+	}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 || !strings.HasPrefix(fields[1], "#") || len(fields[1]) != 7 {
+			log.Fatalf("svgcolors.txt:%d: want a name and a #rrggbb color", n)
+		}
+		colors[strings.ToLower(fields[0])] = strings.ToLower(fields[1])
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+	names := make([]string, 0, len(colors))
+	for k := range colors {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by gencolornames.go from colortab/svgcolors.txt, with rebeccapurple added from CSS Color Level 4. DO NOT EDIT.\n\n")
+	b.WriteString("package svg\n\n")
+	b.WriteString("// colornames maps the CSS named colors to their hex values\n")
+	b.WriteString("var colornames = map[string]string{\n")
+	for _, k := range names {
+		fmt.Fprintf(&b, "\t%q: %q,\n", k, colors[k])
+	}
+	b.WriteString("}\n")
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("colornames.go", src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
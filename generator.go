@@ -0,0 +1,110 @@
+package svg
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GeneratorPlacement specifies where the generator comment is written
+type GeneratorPlacement int
+
+const (
+	// GeneratorAtEnd writes the generator comment before the end of the document
+	GeneratorAtEnd GeneratorPlacement = iota
+	// GeneratorAtStart writes the generator comment after the start of the document
+	GeneratorAtStart
+	// GeneratorOff does not write the generator comment
+	GeneratorOff
+)
+
+// ErrNoClock is latched when a generator timestamp is needed in deterministic mode,
+// but no clock has been set
+var ErrNoClock = errors.New("svg: deterministic mode requires a clock for the generator timestamp")
+
+// generator holds the fields of the generator comment
+type generator struct {
+	name, version string
+	extras        map[string]string
+	at            GeneratorPlacement
+}
+
+// SetGeneratorInfo records the name and version of the tool generating the document, along with
+// extra fields such as a source dataset id, to be written with a timestamp in a comment
+func (svg *SVG) SetGeneratorInfo(name, version string, extras map[string]string) {
+	defer svg.lock()()
+	at := GeneratorAtEnd
+	if svg.generator != nil {
+		at = svg.generator.at
+	}
+	svg.generator = &generator{name: name, version: version, extras: extras, at: at}
+}
+
+// SetGeneratorPlacement specifies where the generator comment is written
+func (svg *SVG) SetGeneratorPlacement(at GeneratorPlacement) {
+	defer svg.lock()()
+	if svg.generator == nil {
+		svg.generator = &generator{}
+	}
+	svg.generator.at = at
+}
+
+// SetClock sets the clock used for the generator timestamp; by default, time.Now
+func (svg *SVG) SetClock(clock func() time.Time) { svg.clock = clock }
+
+// SetDeterministic turns deterministic mode on or off. In deterministic mode the canvas
+// does not read the wall clock: the generator timestamp requires a clock set by SetClock.
+func (svg *SVG) SetDeterministic(on bool) { svg.deterministic = on }
+
+// now returns the time for the generator timestamp, and whether there is one
+func (svg *SVG) now() (time.Time, bool) {
+	switch {
+	case svg.clock != nil:
+		return svg.clock(), true
+	case svg.deterministic:
+		svg.latch(ErrNoClock)
+		return time.Time{}, false
+	}
+	return time.Now(), true
+}
+
+// generated writes the generator comment, if it has been set up to be written at
+func (svg *SVG) generated(at GeneratorPlacement) {
+	g := svg.generator
+	if g == nil || g.at != at || g.name == "" {
+		return
+	}
+	svg.println("<!--")
+	svg.println("generator: " + commentescape(g.name))
+	if g.version != "" {
+		svg.println("version: " + commentescape(g.version))
+	}
+	if t, ok := svg.now(); ok {
+		svg.println("timestamp: " + t.UTC().Format(time.RFC3339))
+	}
+	keys := make([]string, 0, len(g.extras))
+	for k := range g.extras {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		svg.println(commentescape(k) + ": " + commentescape(g.extras[k]))
+	}
+	svg.println("-->")
+}
+
+// commentescape makes s safe within a comment, which may not contain "--",
+// and keeps each field on one line
+func commentescape(s string) string {
+	return commentbody(strings.NewReplacer("\r", " ", "\n", " ").Replace(s))
+}
+
+// commentbody makes s safe within a comment, which may not contain "--"
+func commentbody(s string) string {
+	s = xmlchars(s)
+	for strings.Contains(s, "--") {
+		s = strings.ReplaceAll(s, "--", "- -")
+	}
+	return s
+}
@@ -0,0 +1,433 @@
+package svg
+
+import (
+	"flag"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wildberries-ru/svgo/svgdiff"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// golden compares doc with the golden file testdata/golden/name.svg, rewriting it with -update.
+// On a mismatch, the test fails with a summary of the changes from the golden file.
+func golden(t *testing.T, name string, doc string) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name+".svg")
+	if *update {
+		if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if string(want) == doc {
+		return
+	}
+	changes, err := svgdiff.Compare(want, []byte(doc))
+	if err != nil {
+		t.Errorf("%s differs from %s, which cannot be compared by element (%v):\n%s", name, path, err, svgdiff.Summary(changes))
+		return
+	}
+	if len(changes) == 0 {
+		t.Errorf("%s differs from %s in formatting only:\n%s", name, path, doc)
+		return
+	}
+	t.Errorf("%s differs from %s:\n%s", name, path, svgdiff.Summary(changes))
+}
+
+func TestGoldenShapes(t *testing.T) {
+	golden(t, "shapes", render(t, func(canvas *SVG) {
+		canvas.Rect(10, 10, 30, 20, "fill:red")
+		canvas.Circle(50, 50, 10)
+		canvas.Line(0, 0, 100, 100, "stroke:black")
+	}))
+}
+
+// document returns the document begun by start and drawn by draw
+func document(t *testing.T, start func(*SVG), draw func(*SVG)) string {
+	t.Helper()
+	canvas := NewBuffer()
+	start(canvas)
+	if draw != nil {
+		draw(canvas)
+	}
+	canvas.End()
+	if err := canvas.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	return canvas.String()
+}
+
+func TestGoldenStart(t *testing.T) {
+	for name, start := range map[string]func(*SVG){
+		"start":           func(c *SVG) { c.Start(100, 50, `class="doc"`) },
+		"startunit":       func(c *SVG) { c.Startunit(10, 5, "cm") },
+		"startunitf":      func(c *SVG) { c.Startunitf(10.5, 5.25, "mm") },
+		"startpercent":    func(c *SVG) { c.Startpercent(100, 50) },
+		"startstyled":     func(c *SVG) { c.Startstyled(100, 50, "fill:red") },
+		"startview":       func(c *SVG) { c.Startview(100, 50, 0, 0, 200, 100) },
+		"startviewbox":    func(c *SVG) { c.StartviewBox(100, 50, ViewBox{10, 20, 200, 100}) },
+		"startviewunit":   func(c *SVG) { c.StartviewUnit(10, 5, "in", 0, 0, 100, 50) },
+		"startviewunitf":  func(c *SVG) { c.StartviewUnitf(10.5, 5, "in", 0, 0, 100.5, 50) },
+		"startraw":        func(c *SVG) { c.Startraw(`viewBox="0 0 10 10"`) },
+		"startrawattrs":   func(c *SVG) { c.StartrawAttrs(map[string]string{"width": "10", "id": "a"}) },
+		"startfragment":   func(c *SVG) { c.Startfragment(100, 50, "fill:red") },
+		"startaccessible": func(c *SVG) { c.StartAccessible(100, 50, "Sales", "Sales by <quarter>") },
+		"startparsed": func(c *SVG) {
+			if err := c.StartParsed("10cmx5cm"); err != nil {
+				t.Fatal(err)
+			}
+		},
+		"startpreamble": func(c *SVG) {
+			c.RegisterNamespace("dc", "http://purl.org/dc/elements/1.1/")
+			c.Stylesheet("style.css")
+			c.PI("app", "v=1")
+			c.Start(100, 50)
+		},
+	} {
+		golden(t, name, document(t, start, nil))
+	}
+}
+
+func TestGoldenShapesAll(t *testing.T) {
+	golden(t, "shapes-all", render(t, func(canvas *SVG) {
+		canvas.Circle(10, 10, 5)
+		canvas.Circle(10, 10, 5, "fill:red")
+		canvas.Ellipse(20, 20, 5, 3)
+		canvas.Ellipse(20, 20, 5, 3, "fill:red")
+		canvas.CenterEllipse(20, 20, 10, 6, "fill:red")
+		canvas.Rect(1, 2, 3, 4)
+		canvas.Rect(1, 2, 3, 4, "fill:red", `id="r"`)
+		canvas.CenterRect(50, 50, 10, 20, "fill:red")
+		canvas.CenterSquare(50, 50, 10)
+		canvas.Roundrect(1, 2, 30, 40, 5, 6)
+		canvas.Roundrect(1, 2, 30, 40, 5, 6, "fill:red")
+		canvas.Square(1, 2, 3)
+		canvas.Square(1, 2, 3, "fill:red")
+		canvas.Line(0, 0, 10, 10)
+		canvas.Line(0, 0, 10, 10, "stroke:black")
+		canvas.Polyline([]int{0, 10, 20}, []int{0, 10, 0})
+		canvas.Polyline([]int{0, 10, 20}, []int{0, 10, 0}, "stroke:black")
+		canvas.Polygon([]int{0, 10, 20}, []int{0, 10, 0})
+		canvas.Polygon([]int{0, 10, 20}, []int{0, 10, 0}, "fill:red")
+		canvas.Grid(0, 0, 30, 30, 10, "stroke:gray")
+	}))
+}
+
+func TestGoldenPaths(t *testing.T) {
+	golden(t, "paths", render(t, func(canvas *SVG) {
+		canvas.Path("M0,0L10,10")
+		canvas.Path("M0,0L10,10", "stroke:black")
+		if err := canvas.PathChecked("M0,0 L10,10 Z", "fill:none"); err != nil {
+			t.Error(err)
+		}
+		canvas.Arc(0, 0, 10, 10, 0, false, true, 20, 20)
+		canvas.Arc(0, 0, 10, 10, 0, true, false, 20, 20, "stroke:black")
+		canvas.ArcRot(0, 0, 10, 10, 30, true, true, 20, 20, "stroke:black")
+		canvas.Bezier(0, 0, 10, 10, 20, 20, 30, 30)
+		canvas.Bezier(0, 0, 10, 10, 20, 20, 30, 30, "stroke:black")
+		canvas.Qbez(0, 0, 10, 10, 20, 20)
+		canvas.Qbez(0, 0, 10, 10, 20, 20, "stroke:black")
+		canvas.Qbezier(0, 0, 10, 10, 20, 20, 30, 30)
+		canvas.Qbezier(0, 0, 10, 10, 20, 20, 30, 30, "stroke:black")
+		canvas.Brace(10, 50, 90, 5, false, "stroke:black")
+		canvas.Brace(10, 10, 90, 5, true)
+		canvas.Bracket(10, 50, 90, 5, false, "stroke:black")
+		canvas.Bracket(10, 10, 90, 5, true)
+		canvas.AnnotationArrow(10, 10, 80, 80, 0.3, "note", "stroke:black")
+	}))
+}
+
+func TestGoldenText(t *testing.T) {
+	golden(t, "text", render(t, func(canvas *SVG) {
+		canvas.Text(10, 20, "plain <&>")
+		canvas.Text(10, 20, "styled", "fill:red")
+		canvas.Textspan(10, 30, "span", "font-weight:bold")
+		canvas.Span("inner", "fill:blue")
+		canvas.TextEnd()
+		canvas.Textpath("along", "#p")
+		canvas.Textpath("along", "#p", "fill:red")
+		canvas.TextMultiline(10, 40, "one\ntwo", 1.5, "font-size:10px")
+		canvas.Textlines(10, 60, []string{"a", "b"}, 10, 12, "black", "middle")
+		canvas.Textwrap(10, 80, 40, "the quick brown fox", 10, "fill:black")
+		if err := canvas.TextArcChars(50, 50, 40, 180, 360, "arc", nil, "font-size:8px"); err != nil {
+			t.Error(err)
+		}
+		canvas.Title("title <&>")
+		canvas.TitleAttr("title", `lang="en"`)
+		canvas.Desc("desc <&>")
+		canvas.DescAttr("desc", `lang="en"`)
+		canvas.Comment("comment")
+		canvas.CommentIf(true, "on")
+		canvas.CommentIf(false, "off")
+	}))
+}
+
+func TestGoldenStructure(t *testing.T) {
+	golden(t, "structure", render(t, func(canvas *SVG) {
+		canvas.Def()
+		canvas.Symbol("s")
+		canvas.Circle(5, 5, 5)
+		canvas.SymbolEnd()
+		canvas.SymbolView("sv", 0, 0, 10, 10, "fill:red")
+		canvas.Rect(0, 0, 10, 10)
+		canvas.SymbolEnd()
+		canvas.DefEnd()
+		canvas.Defs(func(c *SVG) { c.Rect(0, 0, 1, 1, `id="dot"`) })
+		canvas.WithDefs(func() { canvas.Circle(0, 0, 1, `id="ring"`) })
+		canvas.Use(10, 10, "#s")
+		canvas.Use(10, 10, "#s", "fill:red")
+		canvas.UseDim(10, 10, 20, 20, "#sv", "fill:red")
+		canvas.Group()
+		canvas.Gend()
+		canvas.Group("fill:red", `id="g"`)
+		canvas.Gend()
+		canvas.GroupAttrs(map[string]string{"id": "ga", "class": "c"})
+		canvas.Gend()
+		canvas.Gclass("a", "b")
+		canvas.Gend()
+		canvas.Gid("gid")
+		canvas.Gend()
+		canvas.Gstyle("fill:red")
+		canvas.Gend()
+		canvas.GroupFn("fill:red", func(c *SVG) { c.Circle(1, 1, 1) })
+		canvas.WithGroup(`id="w"`, func() { canvas.Circle(1, 1, 1) })
+		canvas.GidC("c1")()
+		canvas.GroupC("fill:red")()
+		canvas.GstyleC("fill:blue")()
+		canvas.GroupIf(`systemLanguage="en"`, "fill:red")
+		canvas.Gend()
+		canvas.Switch("fill:red")
+		canvas.SwitchLang("de,de-AT")
+		canvas.Text(0, 0, "Hallo")
+		canvas.Gend()
+		canvas.Group()
+		canvas.Text(0, 0, "Hello")
+		canvas.Gend()
+		canvas.SwitchEnd()
+		canvas.View("v", 0, 0, 50, 50, "xMidYMid")
+		canvas.View("w", 0, 0, 50, 50, "")
+		canvas.InnerSVG(10, 10, 50, 50, "0 0 10 10", "overflow:hidden")
+		canvas.Rect(0, 0, 10, 10)
+		canvas.InnerSVGEnd()
+		canvas.Foreign(0, 0, 50, 50, "fill:red")
+		canvas.ForeignEnd()
+		canvas.ForeignHTML(0, 0, 50, 50, "<p>trusted</p>")
+		canvas.ForeignText(0, 0, 50, 50, "<untrusted>")
+		canvas.AtomicGroup(func(c *SVG) { c.Circle(1, 1, 1) }, "fill:red")
+	}))
+}
+
+func TestGoldenTransforms(t *testing.T) {
+	golden(t, "transforms", render(t, func(canvas *SVG) {
+		canvas.Gtransform("scale(2)")
+		canvas.Gend()
+		canvas.GtransformT(Transform{}.Translate(1, 2).Rotate(45))
+		canvas.Gend()
+		canvas.Translate(10, 20)
+		canvas.Gend()
+		canvas.Rotate(30)
+		canvas.Gend()
+		canvas.Scale(2)
+		canvas.Gend()
+		canvas.ScaleXY(2, 3)
+		canvas.Gend()
+		canvas.SkewX(10)
+		canvas.Gend()
+		canvas.SkewY(10)
+		canvas.Gend()
+		canvas.SkewXY(10, 20)
+		canvas.Gend()
+		canvas.TranslateRotate(10, 20, 30)
+		canvas.Gend()
+		canvas.RotateTranslate(10, 20, 30)
+		canvas.Gend()
+		canvas.GtransformC("scale(3)")()
+		canvas.TranslateC(1, 2)()
+		canvas.RotateC(90)()
+		canvas.ScaleC(0.5)()
+	}))
+}
+
+func TestGoldenLinks(t *testing.T) {
+	golden(t, "links", render(t, func(canvas *SVG) {
+		canvas.Link("http://example.com/?a=1&b=2", "example")
+		canvas.Circle(1, 1, 1)
+		canvas.LinkEnd()
+		canvas.LinkFull("http://example.com", "title", "_blank", "fill:red")
+		canvas.LinkEnd()
+		canvas.Linked("http://example.com", func(c *SVG) { c.Circle(1, 1, 1) }, `target="_top"`)
+		canvas.LinkedCircle("http://example.com", 10, 10, 5, "fill:red")
+		canvas.LinkedRect("http://example.com", 10, 10, 5, 5, "fill:red")
+		canvas.Image(0, 0, 10, 10, "a.png")
+		canvas.Image(0, 0, 10, 10, "a.png", "opacity:0.5")
+		canvas.DescribedCircle(50, 50, 10, "dot", "a red dot", "fill:red")
+		canvas.Described(func(attrs ...string) { canvas.Rect(0, 0, 1, 1, attrs...) }, "box", "")
+	}))
+}
+
+func TestGoldenPaint(t *testing.T) {
+	oc := []Offcolor{{0, "red", 1}, {100, "blue", 0.5}}
+	st := []Stop{{0, "red", 1}, {50.5, "green", -1}, {100, "blue", 0.5}}
+	golden(t, "paint", render(t, func(canvas *SVG) {
+		canvas.Def()
+		canvas.LinearGradient("lg", 0, 0, 100, 0, oc)
+		canvas.LinearGradient("lgs", 0, 0, 100, 0, oc, `gradientTransform="rotate(45)"`)
+		canvas.LinearGradientPercent("lgp", 0, 0, 100, 0, st)
+		canvas.LinearGradientUnits("lgu", 0, 0, 1, 0, "user", oc)
+		canvas.RadialGradient("rg", 50, 50, 50, 50, 50, oc)
+		canvas.RadialGradientExt("rge", 0.5, 0.5, 0.5, 0.5, 0.5, 0.1, "obj", oc)
+		canvas.RadialGradientPercent("rgp", 50, 50, 50, 50, 50, st)
+		canvas.Pattern("p", 0, 0, 10, 10, "user")
+		canvas.Circle(5, 5, 5)
+		canvas.PatternEnd()
+		canvas.PatternFull("pf", 0, 0, 0.5, 0.5, "obj", "user", "rotate(45)", "fill:red")
+		canvas.PatternEnd()
+		canvas.WithPattern("wp", 0, 0, 10, 10, "user", func() { canvas.Circle(5, 5, 5) })
+		canvas.Mask("m", 0, 0, 10, 10)
+		canvas.Rect(0, 0, 10, 10, "fill:white")
+		canvas.MaskEnd()
+		canvas.MaskUnits("mu", 0, 0, 1, 1, "obj", "user", "fill:red")
+		canvas.MaskEnd()
+		canvas.MaskFn("mf", 0, 0, 10, 10, func(c *SVG) { c.Rect(0, 0, 10, 10) })
+		canvas.WithMask("wm", 0, 0, 10, 10, func() { canvas.Rect(0, 0, 10, 10) })
+		canvas.ClipPath(`id="cp"`)
+		canvas.Rect(0, 0, 10, 10)
+		canvas.ClipEnd()
+		canvas.ClipPathUnits("cpu", "obj", "fill:red")
+		canvas.ClipEnd()
+		canvas.ClipRect("cr", 0, 0, 10, 10)
+		canvas.ClipCircle("cc", 5, 5, 5)
+		canvas.WithClip("wc", func() { canvas.Rect(0, 0, 10, 10) })
+		canvas.Marker("mk", 1, 2, 3, 4, "fill:red")
+		canvas.MarkerEnd()
+		canvas.WithMarker("wmk", 1, 2, 3, 4, func() { canvas.Path("M0,0L3,2L0,4z") })
+		canvas.DefEnd()
+		canvas.Rect(0, 0, 10, 10, "fill:url(#lg)")
+	}))
+}
+
+func TestGoldenFilters(t *testing.T) {
+	fs := Filterspec{In: "SourceGraphic", In2: "BackgroundImage", Result: "out"}
+	golden(t, "filters", render(t, func(canvas *SVG) {
+		canvas.Def()
+		canvas.Filter("f")
+		canvas.FeBlend(fs, "multiply")
+		canvas.FeBlend(fs, "screen", `x="0"`)
+		canvas.FeColorMatrix(fs, [20]float64{1, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 1, 0})
+		canvas.FeColorMatrixHue(fs, 90)
+		canvas.FeColorMatrixSaturate(fs, 0.5)
+		canvas.FeColorMatrixLuminanceToAlpha(fs)
+		canvas.FeColorMatrixLuminence(fs)
+		canvas.FeComponentTransfer()
+		canvas.FeFuncLinear("R", 0.5, 0.1)
+		canvas.FeFuncGamma("G", 1, 2, 0)
+		canvas.FeFuncTable("B", []float64{0, 0.5, 1})
+		canvas.FeFuncDiscrete("A", []float64{0, 1})
+		canvas.FeCompEnd()
+		canvas.FeComposite(fs, "over", 0, 0, 0, 0)
+		canvas.FeCompositeArith(fs, 0, 0.5, 0.5, 0)
+		canvas.FeConvolveMatrix(fs, [9]int{0, 1, 0, 1, -4, 1, 0, 1, 0})
+		canvas.FeDiffuseLighting(fs, 1, 1)
+		canvas.FeDistantLight(fs, 45, 30)
+		canvas.FeDiffEnd()
+		canvas.FeSpecularLighting(fs, 1, 1, 20, "white")
+		canvas.FePointLight(1, 2, 3)
+		canvas.FeSpecEnd()
+		canvas.FeSpecularLighting(fs, 1, 1, 20, "")
+		canvas.FeSpotLight(fs, 1, 2, 3, 4, 5, 6)
+		canvas.FeSpecEnd()
+		canvas.FeDisplacementMap(fs, 10, "R", "G")
+		canvas.FeFlood(fs, "red", 0.5)
+		canvas.FeGaussianBlur(fs, 2, 3)
+		canvas.FeImage("a.png", "img")
+		canvas.FeImage("a.png", "img", `x="0"`)
+		canvas.FeMerge([]string{"a", "b"})
+		canvas.FeMorphology(fs, "dilate", 1, 2)
+		canvas.FeOffset(fs, 1, 2)
+		canvas.FeTile(fs, "")
+		canvas.FeTile(fs, "blur")
+		canvas.FeTurbulence(fs, "fractalNoise", 0.1, 0.2, 3, 7, true)
+		canvas.FeTurbulencef(fs, "turbulence", 0.1, 0.2, 3, 7.5, false)
+		canvas.Fend()
+		canvas.Filter("presets", "color-interpolation-filters:sRGB")
+		canvas.Blur(2)
+		canvas.Brightness(50)
+		canvas.Grayscale()
+		canvas.HueRotate(90)
+		canvas.Invert()
+		canvas.Saturate(50)
+		canvas.Sepia()
+		canvas.Fend()
+		canvas.Duotone("duo", "#000080", "#ffff00")
+		canvas.WithFilter("wf", func() { canvas.FeGaussianBlur(Filterspec{}, 1, 1) })
+		canvas.DefEnd()
+		canvas.DuotoneImage(0, 0, 10, 10, "a.png", "#000", "#fff", "opacity:0.5")
+	}))
+}
+
+func TestGoldenAnimation(t *testing.T) {
+	golden(t, "animation", render(t, func(canvas *SVG) {
+		canvas.Circle(10, 10, 5, `id="c"`)
+		canvas.Animate("#c", "cx", 10, 90, 2, 3)
+		canvas.Animate("#c", "cx", 10, 90, 2, -1, `fill="freeze"`)
+		canvas.AnimateRepeat("#c", "cy", 10, 90, 2, Repeat{Dur: 10})
+		canvas.AnimateEased("#c", "r", 5, 10, 1.5, "ease-in-out", 0)
+		canvas.AnimateMotion("#c", "#p", 2, 1)
+		canvas.AnimateMotionRepeat("#c", "#p", 2, Repeat{Count: 2.5}, `rotate="auto"`)
+		canvas.AnimateTransform("#c", "scale", "1", "2", 2, 1)
+		canvas.AnimateTransformRepeat("#c", "scale", "1", "2", 2, Repeat{Count: -1})
+		canvas.AnimateTranslate("#c", 0, 0, 10, 10, 2, 1)
+		canvas.AnimateRotate("#c", 0, 50, 50, 360, 50, 50, 2, 1)
+		canvas.AnimateScale("#c", 1, 2, 2, 1)
+		canvas.AnimateSkewX("#c", 0, 10, 2, 1)
+		canvas.AnimateSkewY("#c", 0, 10, 2, 1)
+	}))
+}
+
+func TestGoldenScripts(t *testing.T) {
+	golden(t, "scripts", render(t, func(canvas *SVG) {
+		canvas.Script("application/javascript", "var a = 1 < 2;")
+		canvas.ScriptLink("application/javascript", "a.js")
+		canvas.ScriptNonce("application/javascript", "abc123", "var b;")
+		canvas.Style("text/css", "circle { fill: red }")
+		canvas.StyleLink("text/css", "a.css")
+	}))
+}
+
+func TestGoldenComposites(t *testing.T) {
+	golden(t, "composites", render(t, func(canvas *SVG) {
+		canvas.Axis(90, Scalemap(0, 10, 10, 80), "stroke:black")
+		canvas.TimeAxis(10, 80, 80, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC), nil, nil, "stroke:black")
+		canvas.Badge(10, 10, "beta", BadgeOpts{Fill: "blue", TextStyle: "fill:white"})
+		canvas.ProgressBar(10, 20, 80, 5, 0.25, "green", "gray", true, `id="pb"`)
+		if err := canvas.SegmentBar(10, 30, 80, 5, []float64{1, 2, 1}, []string{"red", "green", "blue"}, 1); err != nil {
+			t.Error(err)
+		}
+		canvas.RoundedPanel(10, 40, 80, 20, 4, "fill:white", func(c *SVG) { c.Text(5, 10, "panel") })
+		canvas.SeriesGroup([]string{"a b", "c"}, []string{"red", "blue"}, func(i int, name, style string) {
+			canvas.Rect(i*10, 0, 5, 5, style)
+		})
+		canvas.LegendFromSeries(60, 60, LegendOpts{})
+		canvas.DrawPlacedLabels([]PlacedLabel{
+			{Point: LabelPoint{X: 10, Y: 10, Text: "near"}, X: 12, Y: 2, W: 20, H: 10},
+			{Point: LabelPoint{X: 50, Y: 50, Text: "far"}, X: 70, Y: 30, W: 20, H: 10, Shifted: true},
+			{Point: LabelPoint{X: 90, Y: 90, Text: "gone"}, Dropped: true},
+		}, "stroke:gray", "font-size:8px")
+		canvas.ToggleLayer("grid lines", "Grid", false, func(c *SVG) { c.Line(0, 0, 10, 10) })
+		canvas.LayerControls(0, 0)
+		view := image.Rect(0, 0, 50, 50)
+		canvas.Minimap(70, 70, 30, 30, 0, 0, 100, 100, func(c *SVG) { c.Rect(0, 0, 100, 100) }, &view, "stroke:black")
+		img := image.NewGray(image.Rect(0, 0, 2, 2))
+		canvas.ImageAuto(0, 0, img, 10, 10, "opacity:0.5")
+		canvas.TiledImage(0, 0, 20, 20, img, 4)
+	}))
+}
@@ -0,0 +1,67 @@
+package svg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	_ "image/gif"  // decode GIF headers in ImageFileAuto
+	_ "image/jpeg" // decode JPEG headers in ImageFileAuto
+	"image/png"
+	"math"
+	"os"
+)
+
+// ImageAuto places img at x,y, embedded as a data URI, scaled to fit within maxW,maxH keeping its
+// aspect ratio, and returns its placed size. Zero, or negative, maximums leave that dimension unconstrained;
+// with both, the image is placed at its own size. Nothing is drawn for empty images or images that
+// cannot be encoded, and the size is zero.
+func (svg *SVG) ImageAuto(x, y int, img image.Image, maxW, maxH int, s ...string) (w, h int) {
+	b := img.Bounds()
+	if b.Empty() {
+		return 0, 0
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return 0, 0
+	}
+	w, h = fit(b.Dx(), b.Dy(), maxW, maxH)
+	svg.Image(x, y, w, h, "data:image/png;base64,"+base64.StdEncoding.EncodeToString(buf.Bytes()), s...)
+	return w, h
+}
+
+// ImageFileAuto places the PNG, JPEG or GIF image file at path, linked to, at x,y, scaled to fit within
+// maxW,maxH as for ImageAuto, and returns its placed size. Only the header of the file is read,
+// for the size of the image; if it cannot be, nothing is drawn, and the error returned.
+func (svg *SVG) ImageFileAuto(path string, x, y, maxW, maxH int, s ...string) (w, h int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	c, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, err
+	}
+	if c.Width <= 0 || c.Height <= 0 {
+		return 0, 0, nil
+	}
+	w, h = fit(c.Width, c.Height, maxW, maxH)
+	svg.Image(x, y, w, h, path, s...)
+	return w, h, nil
+}
+
+// fit returns the size w,h scaled to fit within maxW,maxH keeping its aspect ratio,
+// ignoring maximums that are not positive
+func fit(w, h, maxW, maxH int) (int, int) {
+	k := math.Inf(1)
+	if maxW > 0 {
+		k = float64(maxW) / float64(w)
+	}
+	if maxH > 0 {
+		k = math.Min(k, float64(maxH)/float64(h))
+	}
+	if math.IsInf(k, 1) {
+		return w, h
+	}
+	return int(math.Max(1, math.Round(float64(w)*k))), int(math.Max(1, math.Round(float64(h)*k)))
+}
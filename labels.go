@@ -0,0 +1,116 @@
+package svg
+
+// LabelPoint is a point to be annotated with a text label
+type LabelPoint struct {
+	X, Y int
+	Text string
+}
+
+// PlacedLabel is a label positioned by PlaceLabels. X, Y, W and H are its bounding box;
+// Shifted labels are away from their point, and Dropped labels could not be placed.
+type PlacedLabel struct {
+	Point   LabelPoint
+	X, Y    int
+	W, H    int
+	Shifted bool
+	Dropped bool
+}
+
+const (
+	labelgap   = 4 // distance of a label from its point
+	labelpoint = 2 // half size of the area kept clear around each point
+	labelstep  = 2 // distance between shifted positions
+)
+
+// labeldirs are the compass directions tried in turn: east, north east, north,
+// north west, west, south west, south and south east
+var labeldirs = [8][2]int{{1, 0}, {1, -1}, {0, -1}, {-1, -1}, {-1, 0}, {-1, 1}, {0, 1}, {1, 1}}
+
+// PlaceLabels positions a label next to each point, trying the eight compass positions
+// around it, then the same positions shifted further away, by up to maxShift, avoiding
+// the labels already placed and all points. Labels are placed greedily in the order given;
+// those that cannot be placed are marked as dropped. The size of each label is given by measure.
+func PlaceLabels(points []LabelPoint, measure func(string) (w, h int), maxShift int) []PlacedLabel {
+	placed := make([]PlacedLabel, len(points))
+	var boxes [][4]int
+	for i, p := range points {
+		w, h := measure(p.Text)
+		placed[i] = PlacedLabel{Point: p, W: w, H: h, Dropped: true}
+	search:
+		for d := 0; d <= maxShift; d += labelstep {
+			for _, dir := range labeldirs {
+				x, y := labelbox(p.X, p.Y, w, h, dir, d)
+				box := [4]int{x, y, x + w, y + h}
+				if labelclear(box, boxes, points) {
+					placed[i] = PlacedLabel{Point: p, X: x, Y: y, W: w, H: h, Shifted: d > 0}
+					boxes = append(boxes, box)
+					break search
+				}
+			}
+		}
+	}
+	return placed
+}
+
+// DrawPlacedLabels draws placed labels, styled by textStyle, with lines styled by leaderStyle
+// leading from shifted labels back to their points. Dropped labels are not drawn.
+func (svg *SVG) DrawPlacedLabels(placed []PlacedLabel, leaderStyle, textStyle string) {
+	for _, l := range placed {
+		if l.Dropped {
+			continue
+		}
+		if l.Shifted {
+			lx, ly := clamp(l.Point.X, l.X, l.X+l.W), clamp(l.Point.Y, l.Y, l.Y+l.H)
+			svg.Line(l.Point.X, l.Point.Y, lx, ly, leaderStyle)
+		}
+		svg.Text(l.X, l.Y+l.H, l.Point.Text, textStyle)
+	}
+}
+
+// labelbox returns the top left corner of a label of dimension w,h in the direction dir
+// from the point x,y, shifted a further distance d
+func labelbox(x, y, w, h int, dir [2]int, d int) (int, int) {
+	bx, by := x-half(w, TruncateTowardTopLeft), y-half(h, TruncateTowardTopLeft)
+	switch dir[0] {
+	case 1:
+		bx = x + labelgap
+	case -1:
+		bx = x - labelgap - w
+	}
+	switch dir[1] {
+	case 1:
+		by = y + labelgap
+	case -1:
+		by = y - labelgap - h
+	}
+	return bx + dir[0]*d, by + dir[1]*d
+}
+
+// labelclear determines if a label box overlaps none of the boxes and points
+func labelclear(box [4]int, boxes [][4]int, points []LabelPoint) bool {
+	for _, b := range boxes {
+		if overlaps(box, b) {
+			return false
+		}
+	}
+	for _, p := range points {
+		if overlaps(box, [4]int{p.X - labelpoint, p.Y - labelpoint, p.X + labelpoint, p.Y + labelpoint}) {
+			return false
+		}
+	}
+	return true
+}
+
+// overlaps determines if the boxes a and b, given as left, top, right and bottom, overlap
+func overlaps(a, b [4]int) bool { return a[0] < b[2] && b[0] < a[2] && a[1] < b[3] && b[1] < a[3] }
+
+// clamp limits v to the range lo-hi
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
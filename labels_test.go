@@ -0,0 +1,97 @@
+package svg
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// cluster returns n points crowded into a square of side size, the same for each seed
+func cluster(seed int64, n, size int) []LabelPoint {
+	r := rand.New(rand.NewSource(seed))
+	points := make([]LabelPoint, n)
+	for i := range points {
+		points[i] = LabelPoint{X: 100 + r.Intn(size), Y: 100 + r.Intn(size), Text: fmt.Sprintf("p%d", i)}
+	}
+	return points
+}
+
+// labelsize measures labels of 6 by 10 units a character
+func labelsize(s string) (int, int) { return 6 * len(s), 10 }
+
+func TestPlaceLabelsDense(t *testing.T) {
+	const maxShift = 20
+	points := cluster(1, 60, 40)
+	placed := PlaceLabels(points, labelsize, maxShift)
+	if len(placed) != len(points) {
+		t.Fatalf("%d labels placed for %d points", len(placed), len(points))
+	}
+	var kept, shifted int
+	for i, a := range placed {
+		if a.Point != points[i] {
+			t.Errorf("label %d is for %v, want %v", i, a.Point, points[i])
+		}
+		if a.Dropped {
+			continue
+		}
+		kept++
+		if a.Shifted {
+			shifted++
+		}
+		box := [4]int{a.X, a.Y, a.X + a.W, a.Y + a.H}
+		if w, h := labelsize(a.Point.Text); a.W != w || a.H != h {
+			t.Errorf("label %s is %dx%d, want %dx%d", a.Point.Text, a.W, a.H, w, h)
+		}
+		for _, b := range placed[i+1:] {
+			if !b.Dropped && overlaps(box, [4]int{b.X, b.Y, b.X + b.W, b.Y + b.H}) {
+				t.Errorf("labels %s at %v and %s at %d,%d overlap", a.Point.Text, box, b.Point.Text, b.X, b.Y)
+			}
+		}
+		for _, p := range points {
+			if overlaps(box, [4]int{p.X - labelpoint, p.Y - labelpoint, p.X + labelpoint, p.Y + labelpoint}) {
+				t.Errorf("label %s at %v covers the point %s", a.Point.Text, box, p.Text)
+			}
+		}
+		if dx, dy := a.X-a.Point.X, a.Y-a.Point.Y; abs(dx) > labelgap+maxShift+a.W || abs(dy) > labelgap+maxShift+a.H {
+			t.Errorf("label %s at %d,%d too far from its point %d,%d", a.Point.Text, a.X, a.Y, a.Point.X, a.Point.Y)
+		}
+	}
+	if kept == 0 || shifted == 0 || kept == len(points) {
+		t.Errorf("%d labels placed, %d shifted, of %d: the cluster is not dense enough to test", kept, shifted, len(points))
+	}
+
+	doc := render(t, func(canvas *SVG) { canvas.DrawPlacedLabels(placed, "stroke:gray", "font-size:8px") })
+	if n := strings.Count(doc, "<text"); n != kept {
+		t.Errorf("%d labels drawn, want %d", n, kept)
+	}
+	if n := strings.Count(doc, "<line"); n != shifted {
+		t.Errorf("%d leaders drawn, want %d", n, shifted)
+	}
+	wellformed(t, doc)
+}
+
+func TestPlaceLabelsDeterministic(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		points := cluster(seed, 30, 50)
+		first := PlaceLabels(points, labelsize, 30)
+		again := PlaceLabels(append([]LabelPoint(nil), points...), labelsize, 30)
+		if !reflect.DeepEqual(first, again) {
+			t.Errorf("seed %d: placement differs between runs", seed)
+		}
+		a := render(t, func(canvas *SVG) { canvas.DrawPlacedLabels(first, "stroke:gray", "") })
+		b := render(t, func(canvas *SVG) { canvas.DrawPlacedLabels(again, "stroke:gray", "") })
+		if a != b {
+			t.Errorf("seed %d: drawing differs between runs", seed)
+		}
+	}
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
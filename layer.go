@@ -0,0 +1,102 @@
+package svg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// layer records a toggleable layer, for use by LayerControls
+type layer struct {
+	class   string
+	label   string
+	visible bool
+}
+
+const (
+	layerprefix  = "layer-"
+	layercontrol = "layer-control"
+	layerbutton  = 16
+	layerspacing = 24
+
+	layerscript = `function svgoToggleLayer(evt, c) {
+	var g = evt.target.ownerDocument.getElementsByClassName(c);
+	for (var i = 0; i < g.length; i++) {
+		g[i].style.display = (g[i].style.display == "none") ? "inline" : "none";
+	}
+}`
+)
+
+// ToggleLayer draws the content of draw inside a group that may be shown or hidden
+// by the buttons emitted by LayerControls. The group's class is derived from name;
+// label is the button text, and visible sets the initial display.
+func (svg *SVG) ToggleLayer(name, label string, visible bool, draw func(*SVG)) {
+	unlock := svg.lock()
+	class := svg.layerclass(name)
+	svg.layers = append(svg.layers, layer{class: class, label: label, visible: visible})
+	unlock()
+	svg.Group(fmt.Sprintf(`class="%s"`, class), "display:"+display(visible))
+	draw(svg)
+	svg.Gend()
+}
+
+// LayerControls places a column of toggle buttons at x,y, one for each layer
+// defined by ToggleLayer, along with the script that toggles them.
+func (svg *SVG) LayerControls(x, y int) {
+	unlock := svg.lock()
+	layers := append([]layer(nil), svg.layers...)
+	blocked := len(layers) == 0 || svg.blocked("layer controls")
+	unlock()
+	if blocked {
+		return
+	}
+	svg.Script("application/javascript", layerscript)
+	svg.Gstyle("font-size:12px;cursor:pointer")
+	for _, l := range layers {
+		svg.Group(fmt.Sprintf(`class="%s" onclick="svgoToggleLayer(evt, '%s')"`, layercontrol, l.class))
+		svg.Rect(x, y, layerbutton, layerbutton, "fill:lightgray;stroke:gray")
+		svg.Text(x+layerbutton+6, y+layerbutton-4, l.label)
+		svg.Gend()
+		y += layerspacing
+	}
+	svg.Gend()
+}
+
+// layerclass makes a class name for a layer from its name, keeping only characters
+// that are safe in both CSS class names and script strings, and distinct from
+// previously defined layers
+func (svg *SVG) layerclass(name string) string {
+	class := layerprefix + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '-'
+	}, name)
+	c, n := class, 1
+	for svg.haslayer(c) {
+		n++
+		c = fmt.Sprintf("%s-%d", class, n)
+	}
+	return c
+}
+
+// haslayer determines if a layer class has already been defined, or is the class of the buttons
+func (svg *SVG) haslayer(class string) bool {
+	if class == layercontrol {
+		return true
+	}
+	for _, l := range svg.layers {
+		if l.class == class {
+			return true
+		}
+	}
+	return false
+}
+
+// display returns the value of the display property for a visibility flag
+func display(visible bool) string {
+	if visible {
+		return "inline"
+	}
+	return "none"
+}
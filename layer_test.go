@@ -0,0 +1,108 @@
+package svg
+
+import (
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLayerControls(t *testing.T) {
+	doc := render(t, func(canvas *SVG) {
+		canvas.ToggleLayer("grid lines", "Grid", true, func(c *SVG) { c.Line(0, 50, 100, 50) })
+		canvas.ToggleLayer("notes", "Notes", false, func(c *SVG) { c.Text(10, 10, "note") })
+		canvas.ToggleLayer("notes", "More notes", true, func(c *SVG) { c.Text(10, 20, "note") })
+		canvas.ToggleLayer("control", "Control", true, func(c *SVG) {})
+		canvas.LayerControls(5, 60)
+	})
+	var (
+		layers   = map[string]string{}
+		controls []string
+		labels   []string
+		script   string
+	)
+	onclick := regexp.MustCompile(`^svgoToggleLayer\(evt, '([^']+)'\)$`)
+	d := xml.NewDecoder(strings.NewReader(doc))
+	for intext := false; ; {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("%v in\n%s", err, doc)
+		}
+		switch e := tok.(type) {
+		case xml.StartElement:
+			attrs := map[string]string{}
+			for _, a := range e.Attr {
+				attrs[a.Name.Local] = a.Value
+			}
+			intext = e.Name.Local == "text"
+			switch {
+			case e.Name.Local == "g" && attrs["class"] == layercontrol:
+				m := onclick.FindStringSubmatch(attrs["onclick"])
+				if m == nil {
+					t.Errorf("onclick %q does not toggle a layer", attrs["onclick"])
+					continue
+				}
+				controls = append(controls, m[1])
+			case e.Name.Local == "g" && strings.HasPrefix(attrs["class"], layerprefix):
+				layers[attrs["class"]] = attrs["style"]
+			}
+		case xml.CharData:
+			if intext {
+				labels = append(labels, string(e))
+			}
+			if strings.Contains(string(e), "function svgoToggleLayer") {
+				script = string(e)
+			}
+		case xml.EndElement:
+			intext = false
+		}
+	}
+	want := map[string]string{
+		"layer-grid-lines": "display:inline",
+		"layer-notes":      "display:none",
+		"layer-notes-2":    "display:inline",
+		"layer-control-2":  "display:inline",
+	}
+	if len(layers) != len(want) {
+		t.Errorf("layers %q, want %q", layers, want)
+	}
+	for class, style := range want {
+		if layers[class] != style {
+			t.Errorf("layer %s: style %q, want %q", class, layers[class], style)
+		}
+	}
+	if got := strings.Join(controls, " "); got != "layer-grid-lines layer-notes layer-notes-2 layer-control-2" {
+		t.Errorf("buttons toggle %s", got)
+	}
+	if got := strings.Join(labels, ", "); got != "note, note, Grid, Notes, More notes, Control" {
+		t.Errorf("labels %s", got)
+	}
+	if !strings.Contains(script, "getElementsByClassName(c)") {
+		t.Errorf("script does not toggle the class it is passed:\n%s", script)
+	}
+}
+
+func TestLayerControlsConcurrent(t *testing.T) {
+	var b strings.Builder
+	canvas := NewSafe(&b)
+	canvas.Start(100, 100)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			canvas.ToggleLayer("layer", "Layer", true, func(c *SVG) {})
+		}()
+	}
+	canvas.LayerControls(0, 0)
+	wg.Wait()
+	canvas.End()
+	if err := canvas.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package svg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidLength is returned, wrapped with the offending token, for lengths that cannot be parsed
+var ErrInvalidLength = errors.New("svg: invalid length")
+
+// lengthunits are the units of SVG lengths, which may also be unitless
+var lengthunits = []string{"px", "em", "ex", "in", "cm", "mm", "pt", "pc", "%"}
+
+// Length is a value in the given unit; the empty unit is user units
+type Length struct {
+	Value float64
+	Unit  string
+}
+
+// String returns the length as written in an attribute
+func (l Length) String() string { return strconv.FormatFloat(l.Value, 'f', -1, 64) + l.Unit }
+
+// ParseLength parses a length, such as "640", "100%" or "210mm", into its value and unit,
+// which must be one of the SVG units, or none.
+func ParseLength(s string) (value float64, unit string, err error) {
+	t := strings.TrimSpace(s)
+	number := t
+	for _, u := range lengthunits {
+		if strings.HasSuffix(t, u) {
+			number, unit = strings.TrimSpace(t[:len(t)-len(u)]), u
+			break
+		}
+	}
+	if unit == "" {
+		if i := strings.LastIndexAny(t, "0123456789."); i >= 0 && i < len(t)-1 {
+			return 0, "", fmt.Errorf("%w %q: unknown unit %q", ErrInvalidLength, s, strings.TrimSpace(t[i+1:]))
+		}
+	}
+	value, perr := strconv.ParseFloat(number, 64)
+	if perr != nil || math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, "", fmt.Errorf("%w %q: invalid number %q", ErrInvalidLength, s, number)
+	}
+	return value, unit, nil
+}
+
+// ParseSize parses a width and height written as "WxH", each with an optional unit,
+// for example "640x480" or "210mmx297mm". A single length is used for both.
+// Negative lengths are invalid.
+func ParseSize(s string) (w, h Length, err error) {
+	var firsterr error
+	for i := 0; i < len(s); i++ {
+		if s[i] != 'x' && s[i] != 'X' {
+			continue
+		}
+		if w, err = parsesizelength(s[:i]); err == nil {
+			if h, err = parsesizelength(s[i+1:]); err == nil {
+				return w, h, nil
+			}
+		}
+		if firsterr == nil {
+			firsterr = err
+		}
+	}
+	if w, err = parsesizelength(s); err == nil {
+		return w, w, nil
+	}
+	if firsterr != nil {
+		err = firsterr
+	}
+	return Length{}, Length{}, err
+}
+
+// parsesizelength parses a length used as a width or height, which may not be negative
+func parsesizelength(s string) (Length, error) {
+	v, u, err := ParseLength(s)
+	if err != nil {
+		return Length{}, err
+	}
+	if v < 0 {
+		return Length{}, fmt.Errorf("%w %q: negative", ErrInvalidLength, s)
+	}
+	return Length{Value: v, Unit: u}, nil
+}
+
+// StartParsed begins the SVG document with the size parsed by ParseSize, using Start, Startunit
+// or Startpercent for whole numbers in a single unit. Other attributes may be optionally added,
+// as for Start. If size cannot be parsed, the document is not started, and the error returned.
+func (svg *SVG) StartParsed(size string, ns ...string) error {
+	w, h, err := ParseSize(size)
+	if err != nil {
+		return err
+	}
+	if w.Unit == h.Unit && whole(w.Value) && whole(h.Value) {
+		switch w.Unit {
+		case "":
+			svg.Start(int(w.Value), int(h.Value), ns...)
+		case "%":
+			svg.Startpercent(int(w.Value), int(h.Value), ns...)
+		default:
+			svg.Startunit(int(w.Value), int(h.Value), w.Unit, ns...)
+		}
+		return nil
+	}
+	defer svg.lock()()
+	svg.begin(fmt.Sprintf(`%s width="%s" height="%s"`, svg.top(), w, h), ns)
+	return nil
+}
+
+// Startunitf begins the SVG document, with fractional width and height in the specified unit,
+// written with the canvas precision. Other attributes may be optionally added, as for Start.
+// Units other than mm, cm, in, pt, pc, px, em and ex are replaced by px, with a warning,
+// and in strict mode latch an error.
+func (svg *SVG) Startunitf(w, h float64, unit string, ns ...string) {
+	defer svg.lock()()
+	unit = svg.physunit(unit)
+	svg.begin(fmt.Sprintf(`%s width="%s%s" height="%s%s"`, svg.top(), svg.num(w), unit, svg.num(h), unit), ns)
+}
+
+// StartviewUnitf begins the SVG document, as Startunitf does, with a viewBox at minx, miny, vw, vh
+func (svg *SVG) StartviewUnitf(w, h float64, unit string, minx, miny, vw, vh float64) {
+	unlock := svg.lock()
+	vb := fmt.Sprintf(`viewBox="%s %s %s %s"`, svg.num(minx), svg.num(miny), svg.num(vw), svg.num(vh))
+	unlock()
+	svg.Startunitf(w, h, unit, vb)
+}
+
+// physunit returns unit if it is a unit of absolute or font relative length, otherwise px
+func (svg *SVG) physunit(unit string) string {
+	switch unit {
+	case "mm", "cm", "in", "pt", "pc", "px", "em", "ex":
+		return unit
+	}
+	svg.warn("invalid unit %q replaced by px", unit)
+	if svg.strict {
+		svg.latch(fmt.Errorf("%w: unknown unit %q", ErrInvalidLength, unit))
+	}
+	return "px"
+}
+
+// whole determines if v is a whole number that fits in an int
+func whole(v float64) bool { return v == math.Trunc(v) && math.Abs(v) <= math.MaxInt32 }
@@ -0,0 +1,118 @@
+package svg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseLength(t *testing.T) {
+	for _, c := range []struct {
+		s     string
+		value float64
+		unit  string
+		bad   string // token named by the error, if the length is invalid
+	}{
+		{"640", 640, "", ""},
+		{" 640px ", 640, "px", ""},
+		{"100%", 100, "%", ""},
+		{"210mm", 210, "mm", ""},
+		{"2.5 cm", 2.5, "cm", ""},
+		{"-1.5em", -1.5, "em", ""},
+		{"1e2pt", 100, "pt", ""},
+		{".5in", 0.5, "in", ""},
+		{"12pc", 12, "pc", ""},
+		{"3ex", 3, "ex", ""},
+		{"12qq", 0, "", `"qq"`},
+		{"12 furlongs", 0, "", `"furlongs"`},
+		{"px", 0, "", `""`},
+		{"", 0, "", `""`},
+		{"abc", 0, "", `"abc"`},
+		{"1.2.3mm", 0, "", `"1.2.3"`},
+		{"NaN", 0, "", `"NaN"`},
+		{"1e999", 0, "", `"1e999"`},
+		{"Infpx", 0, "", `"Inf"`},
+	} {
+		value, unit, err := ParseLength(c.s)
+		if c.bad == "" {
+			if err != nil || value != c.value || unit != c.unit {
+				t.Errorf("ParseLength(%q) = %v, %q, %v, want %v, %q", c.s, value, unit, err, c.value, c.unit)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInvalidLength) || !strings.Contains(err.Error(), c.bad) {
+			t.Errorf("ParseLength(%q) error %v, want ErrInvalidLength naming %s", c.s, err, c.bad)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for _, c := range []struct {
+		s    string
+		w, h Length
+		bad  string // token named by the error, if the size is invalid
+	}{
+		{"640x480", Length{640, ""}, Length{480, ""}, ""},
+		{"640X480", Length{640, ""}, Length{480, ""}, ""},
+		{"640px x 480px", Length{640, "px"}, Length{480, "px"}, ""},
+		{"100%x50%", Length{100, "%"}, Length{50, "%"}, ""},
+		{"210mmx297mm", Length{210, "mm"}, Length{297, "mm"}, ""},
+		{"8.5inx11in", Length{8.5, "in"}, Length{11, "in"}, ""},
+		{"100%x480", Length{100, "%"}, Length{480, ""}, ""},
+		{"210mmx10cm", Length{210, "mm"}, Length{10, "cm"}, ""},
+		{"2exx3em", Length{2, "ex"}, Length{3, "em"}, ""},
+		{"50%", Length{50, "%"}, Length{50, "%"}, ""},
+		{"640", Length{640, ""}, Length{640, ""}, ""},
+		{"640x", Length{}, Length{}, `""`},
+		{"x480", Length{}, Length{}, `""`},
+		{"640x480x2", Length{}, Length{}, `"480x2"`},
+		{"640xtall", Length{}, Length{}, `"tall"`},
+		{"640qqx480", Length{}, Length{}, `"qq"`},
+		{"-640x480", Length{}, Length{}, `"-640"`},
+		{"garbage", Length{}, Length{}, `"garbage"`},
+	} {
+		w, h, err := ParseSize(c.s)
+		if c.bad == "" {
+			if err != nil || w != c.w || h != c.h {
+				t.Errorf("ParseSize(%q) = %v, %v, %v, want %v, %v", c.s, w, h, err, c.w, c.h)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInvalidLength) || !strings.Contains(err.Error(), c.bad) {
+			t.Errorf("ParseSize(%q) error %v, want ErrInvalidLength naming %s", c.s, err, c.bad)
+		}
+	}
+}
+
+func TestStartParsed(t *testing.T) {
+	for _, c := range []struct {
+		size, want string
+	}{
+		{"640x480", `<svg width="640" height="480"`},
+		{"100%x50%", `<svg width="100%" height="50%"`},
+		{"210mmx297mm", `<svg width="210mm" height="297mm"`},
+		{"8.5inx11in", `<svg width="8.5in" height="11in"`},
+		{"100%x480px", `<svg width="100%" height="480px"`},
+		{"64px", `<svg width="64px" height="64px"`},
+	} {
+		canvas := NewBuffer()
+		if err := canvas.StartParsed(c.size, `viewBox="0 0 10 10"`); err != nil {
+			t.Errorf("StartParsed(%q) = %v", c.size, err)
+			continue
+		}
+		canvas.End()
+		doc := canvas.String()
+		if !strings.Contains(doc, c.want) || !strings.Contains(doc, `viewBox="0 0 10 10"`) {
+			t.Errorf("StartParsed(%q): %s\nmissing from\n%s", c.size, c.want, doc)
+		}
+		wellformed(t, doc)
+	}
+
+	canvas := NewBuffer()
+	if err := canvas.StartParsed("640xtall"); !errors.Is(err, ErrInvalidLength) || !strings.Contains(err.Error(), `"tall"`) {
+		t.Errorf("StartParsed(640xtall) = %v, want ErrInvalidLength naming tall", err)
+	}
+	if canvas.String() != "" {
+		t.Errorf("document started for an invalid size:\n%s", canvas.String())
+	}
+}
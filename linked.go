@@ -0,0 +1,73 @@
+package svg
+
+import "strings"
+
+// Linked wraps whatever draw places on the canvas in a link to href. The options are
+// attributes of the link, for example target="_blank" or xlink:title="...".
+func (svg *SVG) Linked(href string, draw func(*SVG), opts ...string) {
+	svg.LinkFull(href, "", "", opts...)
+	draw(svg)
+	svg.LinkEnd()
+}
+
+// LinkedRect draws a rectangle linked to href. The rectangle shows a pointing cursor,
+// unless its style s sets the cursor.
+func (svg *SVG) LinkedRect(href string, x, y, w, h int, s ...string) {
+	svg.Linked(href, func(c *SVG) { c.Rect(x, y, w, h, pointer(s)...) })
+}
+
+// LinkedCircle draws a circle linked to href. The circle shows a pointing cursor,
+// unless its style s sets the cursor.
+func (svg *SVG) LinkedCircle(href string, x, y, r int, s ...string) {
+	svg.Linked(href, func(c *SVG) { c.Circle(x, y, r, pointer(s)...) })
+}
+
+// LinkFull begins a link to href, like Link, with the specified title, target (such as "_blank")
+// and optional attributes, such as rel or class. An empty title or target is omitted. End with LinkEnd.
+func (svg *SVG) LinkFull(href, title, target string, s ...string) {
+	defer svg.lock()()
+	svg.count("a")
+	svg.push("a")
+	if svg.unsafelink(href) {
+		href = ""
+	}
+	svg.checkchars(title, target)
+	var attrs []string
+	if title != "" && !svg.svg2 {
+		attrs = append(attrs, Raw(`xlink:title="`+attrescape(title)+`"`))
+	}
+	if target != "" {
+		attrs = append(attrs, Raw(`target="`+attrescape(target)+`"`))
+	}
+	svg.printf(`<a %s%s`, svg.href(href), svg.endattrs(append(attrs, s...), ">\n"))
+	if svg.svg2 && title != "" {
+		svg.tt("title", title)
+	}
+}
+
+// pointer adds cursor:pointer to the style s, unless it sets the cursor
+func pointer(s []string) []string {
+	for _, v := range s {
+		v = strings.TrimPrefix(v, rawmark)
+		if a, ok := parseattrs(v); ok && strings.Index(v, "=") > 0 {
+			for _, p := range a {
+				if p[0] == "cursor" || p[0] == "style" && stylecursor(p[1]) {
+					return s
+				}
+			}
+		} else if stylecursor(v) {
+			return s
+		}
+	}
+	return append(s[:len(s):len(s)], "cursor:pointer")
+}
+
+// stylecursor determines if the style declarations in v set the cursor
+func stylecursor(v string) bool {
+	for _, d := range strings.Split(v, ";") {
+		if n := strings.Index(d, ":"); n > 0 && strings.TrimSpace(d[:n]) == "cursor" {
+			return true
+		}
+	}
+	return false
+}
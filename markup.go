@@ -0,0 +1,58 @@
+package svg
+
+import (
+	"encoding/xml"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// Markup is a complete document made by FinishMarkup, for inclusion in HTML templates.
+// Only this package makes Markup, so templates and reviewers may rely on its content
+// having been escaped by it. Documents begun by Startfragment suit HTML best.
+type Markup struct {
+	doc string
+}
+
+// markup marks Markup as made by this package
+func (Markup) markup() {}
+
+// HTML returns the document, to be included in an html/template without escaping
+func (m Markup) HTML() template.HTML { return template.HTML(m.doc) }
+
+// String returns the document
+func (m Markup) String() string { return m.doc }
+
+// FinishMarkup ends the document on a canvas made with NewBuffer, if it has not already ended,
+// and returns it as Markup once it passes validation: no errors were recorded, its containers
+// are balanced, and it is well formed XML.
+func (svg *SVG) FinishMarkup() (Markup, error) {
+	if !svg.Capabilities().Buffered {
+		return Markup{}, ErrRequiresBuffer
+	}
+	if svg.state == started {
+		svg.End()
+	}
+	if err := svg.Err(); err != nil {
+		return Markup{}, err
+	}
+	defer svg.lock()()
+	if svg.state != ended {
+		return Markup{}, ErrNoDocument
+	}
+	if len(svg.open) > 0 || len(svg.stray) > 0 {
+		return Markup{}, &UnbalancedError{Open: svg.open, Stray: svg.stray}
+	}
+	doc := svg.doc.String()
+	d := xml.NewDecoder(strings.NewReader(doc))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Markup{}, err
+		}
+	}
+	return Markup{doc: doc}, nil
+}
@@ -0,0 +1,55 @@
+package svg
+
+import "io"
+
+// counter is a writer that discards what is written, counting its bytes
+type counter int64
+
+func (c *counter) Write(p []byte) (int, error) {
+	*c += counter(len(p))
+	return len(p), nil
+}
+
+// NewDiscard is the SVG constructor for canvases that write nothing. Drawing is checked,
+// identifiers allocated and statistics gathered as for any other canvas, so discarding canvases
+// are the way to make passes for layout, or to suppress output, with the same code.
+func NewDiscard() *SVG { return New(new(counter)) }
+
+// Measure runs draw on a canvas configured like this one, discarding its output, and returns
+// the number of bytes and elements it would add to the document, and the first error in drawing it.
+// Nothing is written to the document, and identifiers are allocated as if draw were run on it next.
+func (svg *SVG) Measure(draw func(*SVG)) (bytes int, elements int, err error) {
+	var n counter
+	unlock := svg.lock()
+	var w io.Writer = &n
+	if in, ok := svg.Writer.(*indenter); ok {
+		w = &indenter{w: &n, unit: in.unit, depth: in.depth}
+	}
+	c := svg.clone(w)
+	ids := *c.ids
+	c.ids = &ids
+	unlock()
+	draw(c)
+	c.flush()
+	for _, v := range c.elements {
+		elements += v
+	}
+	err = c.err
+	if err == nil && (len(c.open) > 0 || len(c.stray) > 0) {
+		err = &UnbalancedError{Open: c.open, Stray: c.stray}
+	}
+	return int(n), elements, err
+}
+
+// DrawWithin draws detailed if it adds no more than maxBytes to the document, as measured by Measure,
+// and otherwise fallback, if it is not nil. It reports whether detailed was drawn.
+func (svg *SVG) DrawWithin(maxBytes int, detailed, fallback func(*SVG)) bool {
+	if n, _, err := svg.Measure(detailed); err == nil && n <= maxBytes {
+		detailed(svg)
+		return true
+	}
+	if fallback != nil {
+		fallback(svg)
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package svg
+
+import (
+	"strings"
+	"testing"
+)
+
+// detailed draws content whose size depends on the configuration of the canvas
+func detailed(canvas *SVG) {
+	canvas.Gstyle("fill:navy;stroke:#ff0;stroke-width:0.5")
+	canvas.ClipCircle("lens", 50, 50, 40)
+	canvas.GtransformT(Transform{}.Translate(10.125, 20.5))
+	for i := 0; i < 10; i++ {
+		canvas.Circle(i*10, 50, 5, "opacity:0.1")
+		canvas.Text(i*10, 60, "x & y")
+	}
+	canvas.Gend()
+	canvas.Use(0, 0, "#dot")
+	canvas.Image(0, 0, 10, 10, "http://example.com/a.png")
+	canvas.Script("application/javascript", "var a = 1;")
+	canvas.Gend()
+}
+
+func TestMeasure(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		opts  Options
+		setup func(*SVG)
+	}{
+		{"zero", Options{}, nil},
+		{"Precision", Options{Precision: 1}, nil},
+		{"SVG2", Options{SVG2: true}, nil},
+		{"Indent", Options{Indent: "  "}, nil},
+		{"all", Options{Precision: 2, SVG2: true, Indent: "\t", Strict: true}, nil},
+		{"high contrast", Options{}, func(c *SVG) { c.SetHighContrast(&HighContrast{MinOpacity: 0.2, MinStroke: 1}) }},
+		{"EmailSafe", Options{}, func(c *SVG) { c.SetProfile(EmailSafe) }},
+	} {
+		var b strings.Builder
+		canvas := NewWithOptions(&b, c.opts)
+		if c.setup != nil {
+			c.setup(canvas)
+		}
+		canvas.Start(100, 100)
+		canvas.Gid("outer")
+		before := b.Len()
+		n, elements, err := canvas.Measure(detailed)
+		if err != nil {
+			t.Fatalf("%s: Measure error %v", c.name, err)
+		}
+		if b.Len() != before {
+			t.Errorf("%s: Measure wrote %d bytes to the document", c.name, b.Len()-before)
+		}
+		stats := canvas.Stats()
+		detailed(canvas)
+		if drawn := b.Len() - before; n != drawn {
+			t.Errorf("%s: measured %d bytes, drew %d:\n%s", c.name, n, drawn, b.String()[before:])
+		}
+		drawn := 0
+		for tag, v := range canvas.Stats().Elements {
+			drawn += v - stats.Elements[tag]
+		}
+		if elements != drawn {
+			t.Errorf("%s: measured %d elements, drew %d", c.name, elements, drawn)
+		}
+		canvas.Gend()
+		canvas.End()
+		if err := canvas.Err(); err != nil {
+			t.Fatalf("%s: Err() = %v", c.name, err)
+		}
+		wellformed(t, b.String())
+	}
+}
+
+func TestDrawWithin(t *testing.T) {
+	canvas := NewBuffer()
+	canvas.Start(100, 100)
+	n, _, err := canvas.Measure(detailed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fallback := func(c *SVG) { c.Rect(0, 0, 100, 100, `id="fallback"`) }
+	for _, c := range []struct {
+		budget int
+		drawn  bool
+	}{
+		{n - 1, false},
+		{n, true},
+		{n + 1, true},
+		{0, false},
+	} {
+		var drew bool
+		doc := render(t, func(canvas *SVG) { drew = canvas.DrawWithin(c.budget, detailed, fallback) })
+		if drew != c.drawn {
+			t.Errorf("budget %d for %d bytes: drew detailed %v, want %v", c.budget, n, drew, c.drawn)
+		}
+		if c.drawn == strings.Contains(doc, `id="fallback"`) || c.drawn != strings.Contains(doc, "<clipPath") {
+			t.Errorf("budget %d for %d bytes: drew\n%s", c.budget, n, doc)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package svg
+
+import (
+	"fmt"
+	"image"
+)
+
+const (
+	minimapframe    = "fill:white;stroke:gray"
+	minimapviewport = `fill="none" stroke="red" stroke-width="2" vector-effect="non-scaling-stroke"`
+)
+
+// Minimap draws an overview of a drawing in the box at x,y with dimension w,h.
+// The full extent of the drawing, fullMinX, fullMinY, fullW, fullH, is scaled to fit the box,
+// preserving its aspect ratio; draw is called to render the (typically simplified) content in
+// the coordinates of the full drawing, and may be nil, or place a Use of the main content.
+// When viewportRect is not nil, it is outlined in the same coordinates.
+// The box is framed by a border, styled by s.
+func (svg *SVG) Minimap(x, y, w, h int, fullMinX, fullMinY, fullW, fullH int, draw func(*SVG), viewportRect *image.Rectangle, s ...string) {
+	if len(s) == 0 {
+		s = []string{minimapframe}
+	}
+	unlock := svg.lock()
+	clip := svg.uid("minimap")
+	unlock()
+	svg.Rect(x, y, w, h, s...)
+	svg.ClipPath(fmt.Sprintf(`id="%s"`, clip))
+	svg.Rect(x, y, w, h)
+	svg.ClipEnd()
+	k, tx, ty := minimapscale(x, y, w, h, fullMinX, fullMinY, fullW, fullH)
+	svg.Group(fmt.Sprintf(`clip-path="url(#%s)"`, clip))
+	svg.Gtransform(fmt.Sprintf("translate(%g,%g) %s", tx, ty, scale(k)))
+	if draw != nil {
+		draw(svg)
+	}
+	if viewportRect != nil {
+		r := viewportRect.Canon()
+		svg.Rect(r.Min.X, r.Min.Y, r.Dx(), r.Dy(), minimapviewport)
+	}
+	svg.Gend()
+	svg.Gend()
+}
+
+// minimapscale returns the scale and translation mapping the full extent into the box
+// at x,y with dimension w,h, preserving the aspect ratio and centering the extent
+// along the other axis
+func minimapscale(x, y, w, h int, fullMinX, fullMinY, fullW, fullH int) (k, tx, ty float64) {
+	if fullW <= 0 || fullH <= 0 {
+		return 1, float64(x - fullMinX), float64(y - fullMinY)
+	}
+	k = float64(w) / float64(fullW)
+	if ky := float64(h) / float64(fullH); ky < k {
+		k = ky
+	}
+	tx = float64(x) + (float64(w)-float64(fullW)*k)/2 - float64(fullMinX)*k
+	ty = float64(y) + (float64(h)-float64(fullH)*k)/2 - float64(fullMinY)*k
+	return k, tx, ty
+}
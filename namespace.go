@@ -0,0 +1,41 @@
+package svg
+
+import "strings"
+
+// RegisterNamespace declares the namespace uri, with the prefix, on the svg element written
+// by Start and its variants; registering a prefix again replaces its uri. Namespaces must be
+// registered before the document starts; registering one after latches an error.
+// Invalid prefixes are ignored, with a warning.
+func (svg *SVG) RegisterNamespace(prefix, uri string) {
+	defer svg.lock()()
+	if svg.state != unstarted {
+		svg.latch(&LifecycleError{Op: "RegisterNamespace", Err: ErrStarted})
+		return
+	}
+	if !nsprefix(prefix) {
+		svg.warn("invalid namespace prefix %q omitted", prefix)
+		return
+	}
+	for i, ns := range svg.namespaces {
+		if ns[0] == prefix {
+			svg.namespaces[i][1] = uri
+			return
+		}
+	}
+	svg.namespaces = append(svg.namespaces, [2]string{prefix, uri})
+}
+
+// NSAttr returns the attribute name, in the namespace with the prefix, with the escaped value,
+// for example NSAttr("inkscape", "label", "Layer 1"). Invalid prefixes or names give "".
+func NSAttr(prefix, name, value string) string {
+	if !nsprefix(prefix) || !nsprefix(name) {
+		return ""
+	}
+	return prefix + ":" + name + `="` + attrescape(value) + `"`
+}
+
+// nsprefix determines if s is a valid namespace prefix, or local name: an XML name without colons,
+// not reserved for XML itself
+func nsprefix(s string) bool {
+	return xmlname(s) && !strings.Contains(s, ":") && !strings.HasPrefix(strings.ToLower(s), "xml")
+}
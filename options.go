@@ -0,0 +1,149 @@
+package svg
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+// Options configures a canvas made with NewWithOptions. The zero value configures it as New does.
+type Options struct {
+	Precision       int    // decimal places of fractional values, if positive, as for SetPrecision
+	SVG2            bool   // make references with plain href attributes, as for SetSVG2
+	Indent          string // if not empty, indent each element line by this for each enclosing element
+	Strict          bool   // check the document strictly, as for SetStrict
+	OmitDeclaration bool   // omit the XML declaration
+	Buffer          int    // if positive, buffer output in chunks of this size, as for NewBuffered
+}
+
+// defaultbuffer is the buffer size of canvases made by NewBuffered with a size that is not positive,
+// as for bufio.NewWriter
+const defaultbuffer = 4096
+
+// NewWithOptions is the SVG constructor, specifying the io.Writer where the generated SVG is written,
+// and the configuration of the canvas. If w is nil, nothing is written and the canvas reports
+// ErrNilWriter from Err.
+func NewWithOptions(w io.Writer, opts Options) *SVG {
+	if w == nil {
+		return &SVG{err: ErrNilWriter}
+	}
+	ws, _ := w.(io.WriteSeeker)
+	svg := &SVG{Writer: w, seeker: ws}
+	if opts.Buffer > 0 {
+		svg.buffer = bufio.NewWriterSize(w, opts.Buffer)
+		svg.Writer = svg.buffer
+	}
+	if opts.Indent != "" {
+		svg.Writer = &indenter{w: svg.Writer, unit: opts.Indent}
+	}
+	if opts.Precision > 0 {
+		svg.SetPrecision(opts.Precision)
+	}
+	if opts.OmitDeclaration {
+		svg.SetPreamble(Preamble{Omit: true})
+	}
+	svg.svg2, svg.strict = opts.SVG2, opts.Strict
+	return svg
+}
+
+// indenter indents lines beginning with tags by the depth of the elements enclosing them.
+// Other lines, such as text, comments and character data, are written as they are.
+type indenter struct {
+	w       io.Writer
+	unit    string
+	line    []byte
+	depth   int
+	state   int // indentnormal, indentcomment or indentcdata, at the start of line
+	midline bool
+}
+
+const (
+	indentnormal = iota
+	indentcomment
+	indentcdata
+)
+
+// Write writes the complete lines of p, holding back any partial line
+func (in *indenter) Write(p []byte) (int, error) {
+	in.line = append(in.line, p...)
+	for {
+		i := bytes.IndexByte(in.line, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		err := in.writeline(in.line[:i+1])
+		in.line = in.line[i+1:]
+		if err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Flush writes any partial line, and flushes the writer, if it buffers its output
+func (in *indenter) Flush() error {
+	if len(in.line) > 0 {
+		err := in.writeline(in.line)
+		in.line = in.line[:0]
+		in.midline = true
+		if err != nil {
+			return err
+		}
+	}
+	if f, ok := in.w.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// writeline writes a line, indented if it begins with a tag, and tracks the depth of elements
+func (in *indenter) writeline(line []byte) error {
+	depth := in.depth
+	indent := !in.midline && in.state == indentnormal && len(line) > 0 && line[0] == '<'
+	in.midline = line[len(line)-1] != '\n'
+	if bytes.HasPrefix(line, []byte("</")) {
+		depth--
+	}
+	in.scan(line)
+	if indent && depth > 0 {
+		if _, err := io.WriteString(in.w, strings.Repeat(in.unit, depth)); err != nil {
+			return err
+		}
+	}
+	_, err := in.w.Write(line)
+	return err
+}
+
+// scan follows the elements opened and closed in line
+func (in *indenter) scan(line []byte) {
+	s := string(line)
+	for i := 0; i < len(s); i++ {
+		rest := s[i:]
+		switch in.state {
+		case indentcomment:
+			if strings.HasPrefix(rest, "-->") {
+				in.state, i = indentnormal, i+2
+			}
+		case indentcdata:
+			if strings.HasPrefix(rest, "]]>") {
+				in.state, i = indentnormal, i+2
+			}
+		default:
+			switch {
+			case strings.HasPrefix(rest, "<!--"):
+				in.state, i = indentcomment, i+3
+			case strings.HasPrefix(rest, "<![CDATA["):
+				in.state, i = indentcdata, i+8
+			case strings.HasPrefix(rest, "</"):
+				in.depth--
+			case strings.HasPrefix(rest, "/>"):
+				in.depth--
+			case len(rest) > 1 && rest[0] == '<' && rest[1] != '?' && rest[1] != '!':
+				in.depth++
+			}
+		}
+	}
+	if in.depth < 0 {
+		in.depth = 0
+	}
+}
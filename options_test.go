@@ -0,0 +1,75 @@
+package svg
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNewBuffered(t *testing.T) {
+	for _, size := range []int{0, -1, 16, 4096} {
+		var b, want bytes.Buffer
+		canvas := NewBuffered(&b, size)
+		canvas.Start(100, 100)
+		canvas.Circle(50, 50, 10)
+		if b.Len() != 0 && size != 16 {
+			t.Errorf("size %d: %d bytes written before End", size, b.Len())
+		}
+		canvas.End()
+		if err := canvas.Err(); err != nil {
+			t.Fatalf("size %d: Err() = %v", size, err)
+		}
+		circles := New(&want)
+		circles.Start(100, 100)
+		circles.Circle(50, 50, 10)
+		circles.End()
+		if b.String() != want.String() {
+			t.Errorf("size %d: wrote\n%s\nwant\n%s", size, b.String(), want.String())
+		}
+	}
+}
+
+func TestNewWithOptions(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{"zero", Options{}, []string{`<?xml version="1.0"?>`, `<g transform="translate(50.456,20)">`, `<use x="0" y="0" xlink:href="#c"/>`}},
+		{"Precision", Options{Precision: 1}, []string{`<g transform="translate(50.5,20)">`}},
+		{"SVG2", Options{SVG2: true}, []string{`<use x="0" y="0" href="#c"/>`}},
+		{"Indent", Options{Indent: "  "}, []string{"\n  <g id=\"c\">\n    <g transform", "\n      <circle", "\n  </g>\n  <use"}},
+		{"OmitDeclaration", Options{OmitDeclaration: true}, []string{"<svg width=\"100\""}},
+		{"Buffer", Options{Buffer: 64}, []string{`<use x="0" y="0" xlink:href="#c"/>` + "\n</svg>\n"}},
+	} {
+		var b strings.Builder
+		canvas := NewWithOptions(&b, c.opts)
+		canvas.Start(100, 100)
+		canvas.Gid("c")
+		canvas.GtransformT(Transform{}.Translate(50.456, 20))
+		canvas.Circle(0, 0, 10)
+		canvas.Gend()
+		canvas.Gend()
+		canvas.Use(0, 0, "#c")
+		canvas.End()
+		if err := canvas.Err(); err != nil {
+			t.Fatalf("%s: Err() = %v", c.name, err)
+		}
+		doc := b.String()
+		for _, want := range c.want {
+			if !strings.Contains(doc, want) {
+				t.Errorf("%s: %q missing from\n%s", c.name, want, doc)
+			}
+		}
+		if c.opts.OmitDeclaration && strings.Contains(doc, "<?xml") {
+			t.Errorf("%s: declaration written in\n%s", c.name, doc)
+		}
+		wellformed(t, doc)
+	}
+
+	canvas := NewWithOptions(new(strings.Builder), Options{Strict: true})
+	canvas.Circle(50, 50, 10)
+	if err := canvas.Err(); err == nil {
+		t.Error("Strict: drawing before Start did not latch an error")
+	}
+}
@@ -0,0 +1,150 @@
+package svg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// pathargs is the number of arguments taken by each path command
+var pathargs = map[byte]int{
+	'M': 2, 'L': 2, 'H': 1, 'V': 1, 'C': 6, 'S': 4, 'Q': 4, 'T': 2, 'A': 7, 'Z': 0,
+}
+
+// PathChecked draws the path d, as Path does, if d is valid path data: commands and numbers only,
+// beginning with a move. Invalid path data is not drawn, and an error wrapping ErrInvalidPath returned.
+func (svg *SVG) PathChecked(d string, s ...string) error {
+	if err := pathdata(d); err != nil {
+		return err
+	}
+	svg.Path(d, s...)
+	return nil
+}
+
+// pathdata checks d against the grammar of path data, returning an error wrapping ErrInvalidPath
+// locating the first problem. Empty path data, which disables the path, is valid.
+func pathdata(d string) error {
+	p := pathscan{d: d}
+	p.space()
+	if p.i == len(d) {
+		return nil
+	}
+	if c := d[p.i]; c != 'M' && c != 'm' {
+		return p.fail("path must begin with a move")
+	}
+	for p.space(); p.i < len(d); p.space() {
+		c := d[p.i]
+		n, ok := pathargs[upper(c)]
+		if !ok {
+			return p.fail("unexpected %q", p.rune())
+		}
+		p.i++
+		if n == 0 {
+			continue
+		}
+		for first := true; first || p.number(); first = false {
+			for a := 0; a < n; a++ {
+				if a == 0 {
+					p.space()
+				} else {
+					p.separator()
+				}
+				if upper(c) == 'A' && (a == 3 || a == 4) {
+					if p.i == len(d) || (d[p.i] != '0' && d[p.i] != '1') {
+						return p.fail("arc flag must be 0 or 1")
+					}
+					p.i++
+					continue
+				}
+				if !p.scannumber() {
+					return p.fail("command %c needs %d numbers", c, n)
+				}
+			}
+			p.separator()
+		}
+	}
+	return nil
+}
+
+// pathscan is a position in path data
+type pathscan struct {
+	d string
+	i int
+}
+
+// space skips white space
+func (p *pathscan) space() {
+	for p.i < len(p.d) && strings.IndexByte(" \t\r\n\f", p.d[p.i]) >= 0 {
+		p.i++
+	}
+}
+
+// separator skips white space, with at most one comma
+func (p *pathscan) separator() {
+	p.space()
+	if p.i < len(p.d) && p.d[p.i] == ',' {
+		p.i++
+		p.space()
+	}
+}
+
+// number determines if a number follows
+func (p *pathscan) number() bool {
+	return p.i < len(p.d) && strings.IndexByte("+-.0123456789", p.d[p.i]) >= 0
+}
+
+// scannumber skips a number, with optional sign, fraction and exponent, reporting if there was one
+func (p *pathscan) scannumber() bool {
+	d, i := p.d, p.i
+	if i < len(d) && (d[i] == '+' || d[i] == '-') {
+		i++
+	}
+	digits := 0
+	for ; i < len(d) && isdigit(d[i]); i++ {
+		digits++
+	}
+	if i < len(d) && d[i] == '.' {
+		for i++; i < len(d) && isdigit(d[i]); i++ {
+			digits++
+		}
+	}
+	if digits == 0 {
+		return false
+	}
+	if i < len(d) && (d[i] == 'e' || d[i] == 'E') {
+		j := i + 1
+		if j < len(d) && (d[j] == '+' || d[j] == '-') {
+			j++
+		}
+		if j == len(d) || !isdigit(d[j]) {
+			return false
+		}
+		for i = j; i < len(d) && isdigit(d[i]); i++ {
+		}
+	}
+	p.i = i
+	return true
+}
+
+// rune returns the character at the position
+func (p *pathscan) rune() rune {
+	for _, r := range p.d[p.i:] {
+		return r
+	}
+	return 0
+}
+
+// fail returns an error for a problem at the position
+func (p *pathscan) fail(format string, a ...interface{}) error {
+	return fmt.Errorf("%w at offset %d: %s", ErrInvalidPath, p.i, fmt.Sprintf(format, a...))
+}
+
+// upper returns the upper case of an ASCII letter
+func upper(c byte) byte {
+	if 'a' <= c && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
+
+// isdigit determines if c is an ASCII digit
+func isdigit(c byte) bool { return '0' <= c && c <= '9' }
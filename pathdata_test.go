@@ -0,0 +1,86 @@
+package svg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// pathcases are path data, valid or not, with the problem reported for invalid ones
+var pathcases = []struct {
+	d, problem string
+}{
+	{"", ""},
+	{"M0,0 L10,10 Z", ""},
+	{"  m1 1 l2 2 z  ", ""},
+	{"M1e2,1E-2 L.5.5 l-1-1", ""},
+	{"M-1.5e+3 2.5e-1 H10 V-20 h1e1 v-.25", ""},
+	{"M0 0 C1 1 2 2 3 3 S4 4 5 5 Q6 6 7 7 T8 8", ""},
+	{"M0 0 A10 10 0 1 0 10 10", ""},
+	{"M0 0 a10,10,30,0,1,10,10", ""},
+	{"M0 0 A10 10 0 1110 10", ""},
+	{"M0 0 10 10 20 20", ""},
+	{"L10 10", "path must begin with a move"},
+	{"Z", "path must begin with a move"},
+	{"10 10", "path must begin with a move"},
+	{"M0 0 A10 10 0 2 0 10 10", "arc flag must be 0 or 1"},
+	{"M0 0 A10 10 0 1 -1 10 10", "arc flag must be 0 or 1"},
+	{"M0 0 A10 10 0 true 0 10 10", "arc flag must be 0 or 1"},
+	{"M0 0 L10", "offset 8"},
+	{"M0 0 X1 1", `unexpected 'X'`},
+	{"M0 0 L1e 1", "offset"},
+	{`M0 0"/><script>alert(1)</script>`, `unexpected '"'`},
+	{"M0 0 L<10 10", "offset 6"},
+	{"M0 0 L'10 10", "offset 6"},
+	{"M0 0 &amp;", `unexpected '&'`},
+}
+
+func TestPathChecked(t *testing.T) {
+	for _, c := range pathcases {
+		var err error
+		doc := render(t, func(canvas *SVG) { err = canvas.PathChecked(c.d, "fill:none") })
+		drawn := strings.Contains(doc, "<path")
+		if c.problem == "" {
+			if err != nil || !drawn {
+				t.Errorf("PathChecked(%q) = %v, drawn %v", c.d, err, drawn)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInvalidPath) || !strings.Contains(err.Error(), c.problem) {
+			t.Errorf("PathChecked(%q) = %v, want ErrInvalidPath reporting %s", c.d, err, c.problem)
+		}
+		if drawn {
+			t.Errorf("PathChecked(%q) drew invalid path data:\n%s", c.d, doc)
+		}
+		wellformed(t, doc)
+	}
+}
+
+func TestStrictPath(t *testing.T) {
+	for _, c := range pathcases {
+		var b strings.Builder
+		canvas := NewWithOptions(&b, Options{Strict: true})
+		canvas.Start(100, 100)
+		canvas.Path(c.d)
+		canvas.Circle(50, 50, 10)
+		canvas.End()
+		err := canvas.Err()
+		drawn := strings.Contains(b.String(), "<path")
+		switch {
+		case c.problem == "" && (err != nil || !drawn):
+			t.Errorf("strict Path(%q): Err() = %v, drawn %v", c.d, err, drawn)
+		case c.problem != "" && (!errors.Is(err, ErrInvalidPath) || !strings.Contains(err.Error(), c.problem)):
+			t.Errorf("strict Path(%q): Err() = %v, want ErrInvalidPath reporting %s", c.d, err, c.problem)
+		case c.problem != "" && drawn:
+			t.Errorf("strict Path(%q) drew invalid path data:\n%s", c.d, b.String())
+		}
+		if c.problem != "" {
+			wellformed(t, b.String())
+		}
+	}
+
+	doc := render(t, func(canvas *SVG) { canvas.Path("M0 0 X1 1") })
+	if !strings.Contains(doc, `<path d="M0 0 X1 1"`) {
+		t.Errorf("lenient Path did not draw unchecked path data:\n%s", doc)
+	}
+}
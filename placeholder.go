@@ -0,0 +1,98 @@
+package svg
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// placeholderopen and placeholderclose, from the Unicode private use area, delimit placeholder tokens
+const (
+	placeholderopen  = "\uE000"
+	placeholderclose = "\uE001"
+)
+
+// placeholders records the tokens standing in for values resolved after drawing
+type placeholders struct {
+	prefix string
+	tokens map[string]string
+	limit  int
+}
+
+// Placeholder returns a token standing for the value of key, to be embedded in text or
+// attributes on a canvas made with NewBuffer, and replaced by ResolvePlaceholders.
+// Each key has a single token; tokens are delimited by characters from the Unicode private use area,
+// and include a random per-canvas nonce and fixed width index, so they do not collide with other
+// content, or each other.
+func (svg *SVG) Placeholder(key string) string {
+	defer svg.lock()()
+	p := &svg.placeholders
+	if p.tokens == nil {
+		var nonce [8]byte
+		rand.Read(nonce[:])
+		p.prefix = placeholderopen + "svgo:" + hex.EncodeToString(nonce[:]) + ":"
+		p.tokens = make(map[string]string)
+	}
+	if t, ok := p.tokens[key]; ok {
+		return t
+	}
+	t := fmt.Sprintf("%s%06d%s", p.prefix, len(p.tokens), placeholderclose)
+	p.tokens[key] = t
+	return t
+}
+
+// SetPlaceholderLimit sets the maximum length, in bytes, of placeholder values;
+// zero, the default, places no limit.
+func (svg *SVG) SetPlaceholderLimit(n int) { svg.placeholders.limit = n }
+
+// ResolvePlaceholders replaces the token of each placeholder in the document with its escaped
+// value. Placeholders without a value, or with a value over the limit, are left in place and
+// reported in the returned error.
+func (svg *SVG) ResolvePlaceholders(values map[string]string) error {
+	defer svg.lock()()
+	if !svg.Capabilities().Buffered {
+		return ErrRequiresBuffer
+	}
+	svg.flush()
+	keys := make([]string, 0, len(svg.placeholders.tokens))
+	for key := range svg.placeholders.tokens {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var unresolved, long []string
+	var pairs []string
+	for _, key := range keys {
+		token := svg.placeholders.tokens[key]
+		v, ok := values[key]
+		switch {
+		case !ok:
+			unresolved = append(unresolved, key)
+		case svg.placeholders.limit > 0 && len(v) > svg.placeholders.limit:
+			long = append(long, key)
+		default:
+			var b strings.Builder
+			xml.EscapeText(&b, []byte(v))
+			pairs = append(pairs, token, b.String())
+		}
+	}
+	if len(pairs) > 0 {
+		doc := []byte(strings.NewReplacer(pairs...).Replace(svg.doc.String()))
+		svg.nbytes += int64(len(doc) - svg.doc.Len())
+		svg.doc.Reset()
+		svg.doc.Write(doc)
+	}
+	var problems []string
+	if len(unresolved) > 0 {
+		problems = append(problems, "unresolved "+strings.Join(unresolved, ", "))
+	}
+	if len(long) > 0 {
+		problems = append(problems, fmt.Sprintf("longer than %d bytes %s", svg.placeholders.limit, strings.Join(long, ", ")))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("svg: placeholders %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
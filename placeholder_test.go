@@ -0,0 +1,68 @@
+package svg
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestResolvePlaceholders(t *testing.T) {
+	canvas := NewBuffer()
+	canvas.Start(100, 100)
+	canvas.Text(0, 10, "page "+canvas.Placeholder("page")+" of "+canvas.Placeholder("pages"))
+	canvas.Text(0, 20, canvas.Placeholder("author"))
+	canvas.End()
+
+	err := canvas.ResolvePlaceholders(map[string]string{"page": "3", "pages": "<7>"})
+	if err == nil || !strings.Contains(err.Error(), "unresolved author") {
+		t.Fatalf("error = %v, want unresolved author", err)
+	}
+	doc := canvas.String()
+	if !strings.Contains(doc, ">page 3 of &lt;7&gt;</text>") {
+		t.Errorf("resolved placeholders missing from\n%s", doc)
+	}
+	if !strings.Contains(doc, canvas.Placeholder("author")) {
+		t.Errorf("unresolved placeholder replaced in\n%s", doc)
+	}
+}
+
+func TestResolvePlaceholdersMany(t *testing.T) {
+	canvas := NewBuffer()
+	canvas.Start(100, 100)
+	values := make(map[string]string)
+	for i := 0; i < 12; i++ {
+		key := fmt.Sprint("k", i)
+		values[key] = fmt.Sprint("v", i)
+		canvas.Text(0, i, canvas.Placeholder(key))
+	}
+	canvas.End()
+	if err := canvas.ResolvePlaceholders(values); err != nil {
+		t.Fatal(err)
+	}
+	doc := canvas.String()
+	for i := 0; i < 12; i++ {
+		if want := fmt.Sprintf(">v%d</text>", i); !strings.Contains(doc, want) {
+			t.Errorf("%s missing from\n%s", want, doc)
+		}
+	}
+}
+
+func TestResolvePlaceholdersLimit(t *testing.T) {
+	canvas := NewBuffer()
+	canvas.SetPlaceholderLimit(3)
+	canvas.Start(100, 100)
+	canvas.Text(0, 10, canvas.Placeholder("n"))
+	canvas.End()
+	err := canvas.ResolvePlaceholders(map[string]string{"n": "1234"})
+	if err == nil || !strings.Contains(err.Error(), "longer than 3 bytes n") {
+		t.Fatalf("error = %v, want value over the limit", err)
+	}
+}
+
+func TestResolvePlaceholdersRequiresBuffer(t *testing.T) {
+	var b strings.Builder
+	canvas := New(&b)
+	if err := canvas.ResolvePlaceholders(nil); err != ErrRequiresBuffer {
+		t.Errorf("error = %v, want ErrRequiresBuffer", err)
+	}
+}
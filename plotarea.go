@@ -0,0 +1,68 @@
+package svg
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidMargins is returned for margins that are negative, or leave no room for the plot area
+var ErrInvalidMargins = errors.New("svg: invalid margins")
+
+// PlotArea is the region of a chart inside its margins
+type PlotArea struct {
+	x, y, w, h int
+}
+
+// NewPlotArea returns the plot area of a canvas of dimension canvasW,canvasH, inside the margins
+// top, right, bottom and left. Negative margins, or margins leaving no room, are invalid.
+func NewPlotArea(canvasW, canvasH int, top, right, bottom, left int) (PlotArea, error) {
+	if top < 0 || right < 0 || bottom < 0 || left < 0 {
+		return PlotArea{}, fmt.Errorf("%w: negative margin", ErrInvalidMargins)
+	}
+	w, h := canvasW-left-right, canvasH-top-bottom
+	if w <= 0 || h <= 0 {
+		return PlotArea{}, fmt.Errorf("%w: no room in %dx%d", ErrInvalidMargins, canvasW, canvasH)
+	}
+	return PlotArea{x: left, y: top, w: w, h: h}, nil
+}
+
+// X0 returns the left edge of the plot area
+func (p PlotArea) X0() int { return p.x }
+
+// Y0 returns the top edge of the plot area
+func (p PlotArea) Y0() int { return p.y }
+
+// W returns the width of the plot area
+func (p PlotArea) W() int { return p.w }
+
+// H returns the height of the plot area
+func (p PlotArea) H() int { return p.h }
+
+// Inner returns the plot area inset by padding on each side, shrinking to nothing about its center
+func (p PlotArea) Inner(padding int) PlotArea {
+	px, py := clamp(padding, 0, p.w/2), clamp(padding, 0, p.h/2)
+	return PlotArea{x: p.x + px, y: p.y + py, w: p.w - 2*px, h: p.h - 2*py}
+}
+
+// ClipTo defines a clip path of the plot area on c, and returns the attribute clipping to it
+func (p PlotArea) ClipTo(c *SVG) string {
+	unlock := c.lock()
+	clip := c.uid("plot")
+	unlock()
+	c.WithClip(clip, func() { c.Rect(p.x, p.y, p.w, p.h) })
+	return fmt.Sprintf(`clip-path="url(#%s)"`, clip)
+}
+
+// Frame draws the border of the plot area on c, styled by style
+func (p PlotArea) Frame(c *SVG, style string) {
+	c.Rect(p.x, p.y, p.w, p.h, "fill:none;stroke:black", style)
+}
+
+// XScale returns a linear scale from the values minV-maxV across the plot area
+func (p PlotArea) XScale(minV, maxV float64) *Linear { return Scalemap(minV, maxV, p.x, p.w) }
+
+// YScale returns a linear scale from the values minV-maxV up the plot area
+func (p PlotArea) YScale(minV, maxV float64) *Linear { return Scalemap(maxV, minV, p.y, p.h) }
+
+// XAxis draws the axis for the scale sc, as Axis does, along the bottom of the plot area on c
+func (p PlotArea) XAxis(c *SVG, sc Scaler, s ...string) { c.Axis(p.y+p.h, sc, s...) }
@@ -0,0 +1,166 @@
+package svg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Profile restricts the generated SVG to a subset of the language
+type Profile int
+
+const (
+	// Full places no restrictions on the generated SVG
+	Full Profile = iota
+	// EmailSafe omits scripts, animation, foreign objects and external references,
+	// which are stripped by most email clients
+	EmailSafe
+)
+
+const emailtop = `<?xml version="1.0" encoding="UTF-8"?>
+<svg`
+
+// SetProfile restricts subsequent output to the profile p.
+// Features blocked by the profile are not emitted, and are reported by Warnings.
+func (svg *SVG) SetProfile(p Profile) { svg.profile = p }
+
+// Warnings returns a copy of the warnings recorded while generating the document
+func (svg *SVG) Warnings() []string {
+	defer svg.lock()()
+	return append([]string(nil), svg.warnings...)
+}
+
+// warn records a warning
+func (svg *SVG) warn(format string, a ...interface{}) {
+	svg.warnings = append(svg.warnings, fmt.Sprintf(format, a...))
+}
+
+// Preamble specifies what precedes the svg element of a document
+type Preamble struct {
+	Omit       bool   // omit the XML declaration, for example when inlining the document in HTML
+	Encoding   string // encoding named by the declaration, if any
+	Standalone string // standalone flag of the declaration ("yes" or "no"), if any
+	Doctype    string // document type declaration, for example SVG11Doctype, if any
+}
+
+// SVG11Doctype is the document type declaration of SVG 1.1
+const SVG11Doctype = `<!DOCTYPE svg PUBLIC "-//W3C//DTD SVG 1.1//EN" "http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd">`
+
+// SetPreamble sets the preamble written when the document starts.
+// By default, the XML declaration alone is written.
+func (svg *SVG) SetPreamble(p Preamble) { svg.preamble = &p }
+
+// SetSVG2 turns SVG 2 linking on or off. With SVG 2 linking, references are made with plain
+// href attributes, the xlink namespace is not declared, and link titles are title elements.
+func (svg *SVG) SetSVG2(on bool) { svg.svg2 = on }
+
+// SetFragment turns fragment output on or off. Fragments, for inclusion in HTML documents,
+// have no XML declaration or preamble, and their svg element declares no namespaces
+// other than those registered. Any of the Start methods may begin a fragment.
+func (svg *SVG) SetFragment(on bool) { svg.fragment = on }
+
+// PI adds the processing instruction with the target and data to the preamble, after the
+// XML declaration. Processing instructions must be added before the document starts;
+// adding one after latches an error. Invalid targets are ignored, with a warning.
+func (svg *SVG) PI(target, data string) {
+	defer svg.lock()()
+	if svg.state != unstarted {
+		svg.latch(&LifecycleError{Op: "PI", Err: ErrStarted})
+		return
+	}
+	if !xmlname(target) || strings.Contains(target, ":") || strings.EqualFold(target, "xml") {
+		svg.warn("invalid processing instruction target %q omitted", target)
+		return
+	}
+	data = xmlchars(data)
+	for strings.Contains(data, "?>") {
+		data = strings.ReplaceAll(data, "?>", "? >")
+	}
+	pi := "<?" + target
+	if data != "" {
+		pi += " " + data
+	}
+	svg.pis = append(svg.pis, pi+"?>")
+}
+
+// Stylesheet links the document to the CSS style sheet at href, with an xml-stylesheet
+// processing instruction, which must be added before the document starts, as for PI
+func (svg *SVG) Stylesheet(href string) {
+	svg.PI("xml-stylesheet", `type="text/css" href="`+attrescape(href)+`"`)
+}
+
+// top returns the beginning of the document, according to the profile and preamble
+func (svg *SVG) top() string {
+	if svg.fragment {
+		return "<svg"
+	}
+	p := svg.preamble
+	if p == nil {
+		switch {
+		case len(svg.pis) > 0:
+			p = &Preamble{}
+		case svg.profile == EmailSafe:
+			return emailtop
+		default:
+			return svgtop
+		}
+	}
+	var b strings.Builder
+	if !p.Omit {
+		encoding := p.Encoding
+		if encoding == "" && svg.profile == EmailSafe {
+			encoding = "UTF-8"
+		}
+		b.WriteString(`<?xml version="1.0"`)
+		if encoding != "" {
+			b.WriteString(` encoding="` + attrescape(encoding) + `"`)
+		}
+		if p.Standalone != "" {
+			b.WriteString(` standalone="` + attrescape(p.Standalone) + `"`)
+		}
+		b.WriteString("?>\n")
+	}
+	for _, pi := range svg.pis {
+		b.WriteString(pi + "\n")
+	}
+	if p.Doctype != "" {
+		b.WriteString(p.Doctype + "\n")
+	}
+	b.WriteString("<svg")
+	return b.String()
+}
+
+// blocked determines if the profile excludes the feature, recording it if so
+func (svg *SVG) blocked(feature string) bool {
+	if svg.profile != EmailSafe {
+		return false
+	}
+	if svg.blocks == nil {
+		svg.blocks = make(map[string]int)
+	}
+	svg.blocks[feature]++
+	svg.warn("email-safe profile: %s blocked", feature)
+	return true
+}
+
+// blockedref determines if the profile excludes a reference to link from the feature.
+// Only data: URIs and fragments within the document are allowed in the EmailSafe profile.
+func (svg *SVG) blockedref(feature, link string) bool {
+	if svg.profile != EmailSafe || strings.HasPrefix(link, "data:") || strings.HasPrefix(link, "#") {
+		return false
+	}
+	return svg.blocked(feature + " with external reference")
+}
+
+// blockreport records a summary of the features blocked by the profile
+func (svg *SVG) blockreport() {
+	if len(svg.blocks) == 0 {
+		return
+	}
+	features := make([]string, 0, len(svg.blocks))
+	for f, n := range svg.blocks {
+		features = append(features, fmt.Sprintf("%s (%d)", f, n))
+	}
+	sort.Strings(features)
+	svg.warn("email-safe profile: blocked %s", strings.Join(features, ", "))
+}
@@ -0,0 +1,109 @@
+package svg
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+// kitchensink draws everything the EmailSafe profile blocks, along with what it allows
+func kitchensink(canvas *SVG) {
+	canvas.Def()
+	canvas.Circle(0, 0, 5, `id="dot"`)
+	canvas.DefEnd()
+	canvas.Script("application/javascript", "alert(1)")
+	canvas.ScriptNonce("application/javascript", "n", "alert(2)")
+	canvas.ScriptLink("application/javascript", "http://example.com/a.js")
+	canvas.Image(0, 0, 10, 10, "http://example.com/a.png")
+	canvas.Image(0, 0, 10, 10, "data:image/png;base64,iVBORw0KGgo=")
+	canvas.Use(10, 10, "http://example.com/sprites.svg#dot")
+	canvas.UseDim(10, 10, 5, 5, "sprites.svg#dot")
+	canvas.Use(20, 20, "#dot")
+	canvas.Filter("f")
+	canvas.FeImage("http://example.com/a.png", "img")
+	canvas.Fend()
+	canvas.Animate("#dot", "r", 5, 10, 2, 1)
+	canvas.AnimateMotion("#dot", "#path", 2, 1)
+	canvas.AnimateTranslate("#dot", 0, 0, 10, 10, 2, 1)
+	canvas.AnimateRotate("#dot", 0, 0, 0, 90, 0, 0, 2, 1)
+	canvas.AnimateScale("#dot", 1, 2, 2, 1)
+	canvas.AnimateSkewX("#dot", 0, 10, 2, 1)
+	canvas.Foreign(0, 0, 50, 50)
+	canvas.Foreign(0, 0, 10, 10)
+	canvas.ForeignEnd()
+	canvas.Circle(1, 1, 1, `id="inforeign"`)
+	canvas.ForeignEnd()
+	canvas.ForeignHTML(0, 0, 50, 50, "<p>html</p>")
+	canvas.ForeignText(0, 0, 50, 50, "text")
+	canvas.ToggleLayer("grid", "Grid", true, func(c *SVG) { c.Line(0, 50, 100, 50) })
+	canvas.LayerControls(0, 0)
+	canvas.Rect(0, 0, 100, 100, "fill:none;stroke:black")
+}
+
+func TestEmailSafe(t *testing.T) {
+	canvas := NewBuffer()
+	canvas.SetProfile(EmailSafe)
+	canvas.Start(100, 100)
+	kitchensink(canvas)
+	if err := canvas.EndChecked(); err != nil {
+		t.Fatalf("EndChecked() = %v", err)
+	}
+	doc := canvas.String()
+	if !strings.HasPrefix(doc, `<?xml version="1.0" encoding="UTF-8"?>`+"\n<svg") {
+		t.Errorf("prolog of\n%s", doc)
+	}
+	elements := map[string]int{}
+	d := xml.NewDecoder(strings.NewReader(doc))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("%v in\n%s", err, doc)
+		}
+		e, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		elements[e.Name.Local]++
+		for _, a := range e.Attr {
+			if a.Name.Local == "href" && !strings.HasPrefix(a.Value, "#") && !strings.HasPrefix(a.Value, "data:") {
+				t.Errorf("external reference %s in <%s>", a.Value, e.Name.Local)
+			}
+			if strings.HasPrefix(a.Name.Local, "on") {
+				t.Errorf("event handler %s in <%s>", a.Name.Local, e.Name.Local)
+			}
+		}
+	}
+	for _, tag := range []string{"script", "animate", "animateMotion", "animateTransform", "foreignObject", "feImage", "p", "div"} {
+		if elements[tag] != 0 {
+			t.Errorf("%d <%s> elements in\n%s", elements[tag], tag, doc)
+		}
+	}
+	for tag, n := range map[string]int{"image": 1, "use": 1, "rect": 1, "line": 1, "circle": 1} {
+		if elements[tag] != n {
+			t.Errorf("%d <%s> elements, want %d, in\n%s", elements[tag], tag, n, doc)
+		}
+	}
+
+	warnings := canvas.Warnings()
+	want := "email-safe profile: blocked animate (1), animateMotion (1), animateTransform (4), " +
+		"feImage with external reference (1), foreignObject (3), image with external reference (1), " +
+		"layer controls (1), script (3), use with external reference (2)"
+	if len(warnings) == 0 || warnings[len(warnings)-1] != want {
+		t.Errorf("report %q, want %q", warnings, want)
+	}
+	warnings[0] = "changed"
+	if canvas.Warnings()[0] == "changed" {
+		t.Error("Warnings() returned the canvas's own warnings")
+	}
+
+	full := render(t, kitchensink)
+	for _, tag := range []string{"<script", "<animate", "<foreignObject", "<feImage", "http://example.com"} {
+		if !strings.Contains(full, tag) {
+			t.Errorf("%s missing without the profile from\n%s", tag, full)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package svg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ErrNotSVG is returned when the root element of a document is not an svg element
+var ErrNotSVG = errors.New("svg: root element is not svg")
+
+// Resize returns the document svgBytes with the width and height of its root element set to
+// newW and newH, in the units of the existing width and height, if any. If addViewBoxIfMissing is set,
+// a root element without a viewBox is given one from its existing width and height, so that
+// its contents are scaled to the new size; percentages, which give no dimension, are not used.
+// Only the root element is changed; the rest of the document is left as it is.
+func Resize(svgBytes []byte, newW, newH int, addViewBoxIfMissing bool) ([]byte, error) {
+	return resize(svgBytes, Length{Value: float64(newW)}, Length{Value: float64(newH)}, true, addViewBoxIfMissing)
+}
+
+// ResizeLength is like Resize, but sets the width and height to w and h in their own units,
+// in place of the existing units.
+func ResizeLength(svgBytes []byte, w, h Length, addViewBoxIfMissing bool) ([]byte, error) {
+	return resize(svgBytes, w, h, false, addViewBoxIfMissing)
+}
+
+// resize sets the width and height of the root element of doc to w and h, in the existing
+// units if keepunits is set, adding a view box if addvb is set and there is none
+func resize(doc []byte, w, h Length, keepunits bool, addvb bool) ([]byte, error) {
+	for _, l := range []Length{w, h} {
+		if _, err := parsesizelength(l.String()); err != nil {
+			return nil, err
+		}
+	}
+	start, end, err := rootspan(doc)
+	if err != nil {
+		return nil, err
+	}
+	tag := doc[start:end]
+	attrs := tagattrs(tag)
+	var edits []tagedit
+	var old [2]Length
+	var found [2]bool
+	viewbox := false
+	for _, a := range attrs {
+		i := -1
+		switch a.name {
+		case "width":
+			i = 0
+		case "height":
+			i = 1
+		case "viewBox":
+			viewbox = true
+		}
+		if i < 0 {
+			continue
+		}
+		v, unit, perr := ParseLength(string(tag[a.vs:a.ve]))
+		if perr != nil {
+			return nil, perr
+		}
+		old[i], found[i] = Length{Value: v, Unit: unit}, true
+		size := [2]Length{w, h}[i]
+		if keepunits {
+			size.Unit = unit
+		}
+		edits = append(edits, tagedit{a.vs, a.ve, size.String()})
+	}
+	var add string
+	if !found[0] {
+		add += ` width="` + w.String() + `"`
+	}
+	if !found[1] {
+		add += ` height="` + h.String() + `"`
+	}
+	if addvb && !viewbox && found[0] && found[1] && old[0].Unit != "%" && old[1].Unit != "%" {
+		add += fmt.Sprintf(` viewBox="0 0 %s %s"`,
+			strconv.FormatFloat(old[0].Value, 'f', -1, 64), strconv.FormatFloat(old[1].Value, 'f', -1, 64))
+	}
+	if add != "" {
+		edits = append([]tagedit{{tagname(tag), tagname(tag), add}}, edits...)
+	}
+	var b bytes.Buffer
+	b.Grow(len(doc) + len(add) + 16)
+	b.Write(doc[:start])
+	at := 0
+	for _, e := range edits {
+		b.Write(tag[at:e.start])
+		b.WriteString(e.text)
+		at = e.end
+	}
+	b.Write(tag[at:])
+	b.Write(doc[end:])
+	return b.Bytes(), nil
+}
+
+// tagattr locates the value of an attribute within a tag
+type tagattr struct {
+	name   string
+	vs, ve int
+}
+
+// tagedit replaces the bytes from start to end of a tag with text
+type tagedit struct {
+	start, end int
+	text       string
+}
+
+// rootspan returns the offsets of the start tag of the root element of doc,
+// which must be an svg element
+func rootspan(doc []byte) (start, end int, err error) {
+	d := xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		offset := d.InputOffset()
+		t, err := d.RawToken()
+		if err == io.EOF {
+			return 0, 0, ErrNoDocument
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		if e, ok := t.(xml.StartElement); ok {
+			if e.Name.Local != "svg" {
+				return 0, 0, ErrNotSVG
+			}
+			return int(offset), int(d.InputOffset()), nil
+		}
+	}
+}
+
+// tagname returns the offset of the end of the element name in tag
+func tagname(tag []byte) int {
+	if i := bytes.IndexAny(tag, " \t\r\n/>"); i >= 0 {
+		return i
+	}
+	return len(tag)
+}
+
+// tagattrs returns the attributes of the well formed start tag, with the offsets of their values
+func tagattrs(tag []byte) []tagattr {
+	var attrs []tagattr
+	space := func(c byte) bool { return c == ' ' || c == '\t' || c == '\r' || c == '\n' }
+	i := tagname(tag)
+	for i < len(tag) {
+		for i < len(tag) && space(tag[i]) {
+			i++
+		}
+		if i >= len(tag) || tag[i] == '/' || tag[i] == '>' {
+			break
+		}
+		n := i
+		for i < len(tag) && tag[i] != '=' && !space(tag[i]) {
+			i++
+		}
+		name := string(tag[n:i])
+		for i < len(tag) && (tag[i] == '=' || space(tag[i])) {
+			i++
+		}
+		if i >= len(tag) {
+			break
+		}
+		quote := tag[i]
+		end := bytes.IndexByte(tag[i+1:], quote)
+		if end < 0 {
+			break
+		}
+		attrs = append(attrs, tagattr{name, i + 1, i + 1 + end})
+		i += end + 2
+	}
+	return attrs
+}
@@ -0,0 +1,110 @@
+package svg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"testing"
+)
+
+// rootattrs returns the width, height and viewBox of the root element of doc, failing the test
+// if doc is not well formed, and the bytes following the root start tag
+func rootattrs(t *testing.T, doc []byte) (attrs [3]string, rest []byte) {
+	t.Helper()
+	_, end, err := rootspan(doc)
+	if err != nil {
+		t.Fatalf("%v in\n%s", err, doc)
+	}
+	var root struct {
+		Width   string `xml:"width,attr"`
+		Height  string `xml:"height,attr"`
+		ViewBox string `xml:"viewBox,attr"`
+	}
+	if err := xml.Unmarshal(doc, &root); err != nil {
+		t.Fatalf("%v in\n%s", err, doc)
+	}
+	return [3]string{root.Width, root.Height, root.ViewBox}, doc[end:]
+}
+
+func TestResize(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		start    func(*SVG)
+		resized  [3]string // width, height and viewBox after Resize to 300x200, adding a viewBox
+		original string    // the original viewBox
+	}{
+		{"Start", func(c *SVG) { c.Start(100, 50) }, [3]string{"300", "200", "0 0 100 50"}, ""},
+		{"Startstyled", func(c *SVG) { c.Startstyled(100, 50, "fill:red") }, [3]string{"300", "200", "0 0 100 50"}, ""},
+		{"Startfragment", func(c *SVG) { c.Startfragment(100, 50) }, [3]string{"300", "200", "0 0 100 50"}, ""},
+		{"Startunit", func(c *SVG) { c.Startunit(100, 50, "mm") }, [3]string{"300mm", "200mm", "0 0 100 50"}, ""},
+		{"Startunitf", func(c *SVG) { c.Startunitf(100.5, 50.25, "cm") }, [3]string{"300cm", "200cm", "0 0 100.5 50.25"}, ""},
+		{"Startpercent", func(c *SVG) { c.Startpercent(100, 50) }, [3]string{"300%", "200%", ""}, ""},
+		{"Startview", func(c *SVG) { c.Startview(100, 50, 0, 0, 10, 5) }, [3]string{"300", "200", "0 0 10 5"}, "0 0 10 5"},
+		{"StartviewUnit", func(c *SVG) { c.StartviewUnit(100, 50, "in", 0, 0, 10, 5) }, [3]string{"300in", "200in", "0 0 10 5"}, "0 0 10 5"},
+		{"Startraw", func(c *SVG) { c.Startraw(`viewBox="0 0 10 5"`) }, [3]string{"300", "200", "0 0 10 5"}, "0 0 10 5"},
+		{"StartAccessible", func(c *SVG) { c.StartAccessible(100, 50, "title", "desc") }, [3]string{"300", "200", "0 0 100 50"}, ""},
+		{"StartParsed", func(c *SVG) { c.StartParsed("100%x480px") }, [3]string{"300%", "200px", ""}, ""},
+	} {
+		canvas := NewBuffer()
+		c.start(canvas)
+		canvas.Circle(10, 10, 5)
+		canvas.End()
+		doc := canvas.Bytes()
+		_, rest := rootattrs(t, doc)
+
+		out, err := Resize(doc, 300, 200, true)
+		if err != nil {
+			t.Fatalf("%s: Resize error %v", c.name, err)
+		}
+		attrs, after := rootattrs(t, out)
+		if attrs != c.resized {
+			t.Errorf("%s: Resize made %q, want %q", c.name, attrs, c.resized)
+		}
+		if !bytes.Equal(after, rest) {
+			t.Errorf("%s: Resize changed more than the root element:\n%s", c.name, out)
+		}
+
+		out, err = Resize(doc, 300, 200, false)
+		if err != nil {
+			t.Fatalf("%s: Resize error %v", c.name, err)
+		}
+		if attrs, _ := rootattrs(t, out); attrs != [3]string{c.resized[0], c.resized[1], c.original} {
+			t.Errorf("%s: Resize without adding a viewBox made %q", c.name, attrs)
+		}
+
+		out, err = ResizeLength(doc, Length{50, "%"}, Length{3, "in"}, true)
+		if err != nil {
+			t.Fatalf("%s: ResizeLength error %v", c.name, err)
+		}
+		attrs, after = rootattrs(t, out)
+		if want := [3]string{"50%", "3in", c.resized[2]}; attrs != want {
+			t.Errorf("%s: ResizeLength made %q, want %q", c.name, attrs, want)
+		}
+		if !bytes.Equal(after, rest) {
+			t.Errorf("%s: ResizeLength changed more than the root element:\n%s", c.name, out)
+		}
+	}
+}
+
+func TestResizeErrors(t *testing.T) {
+	doc := []byte(`<svg width="10" height="10"></svg>`)
+	for _, c := range []struct {
+		name string
+		doc  []byte
+		w, h Length
+		want error
+	}{
+		{"negative", doc, Length{-1, ""}, Length{10, ""}, ErrInvalidLength},
+		{"unit", doc, Length{10, "furlong"}, Length{10, ""}, ErrInvalidLength},
+		{"old dimension", []byte(`<svg width="wide" height="10"></svg>`), Length{10, ""}, Length{10, ""}, ErrInvalidLength},
+		{"not svg", []byte(`<?xml version="1.0"?><html></html>`), Length{10, ""}, Length{10, ""}, ErrNotSVG},
+		{"empty", []byte(`<?xml version="1.0"?>`), Length{10, ""}, Length{10, ""}, ErrNoDocument},
+	} {
+		if _, err := ResizeLength(c.doc, c.w, c.h, true); !errors.Is(err, c.want) {
+			t.Errorf("%s: error %v, want %v", c.name, err, c.want)
+		}
+	}
+	if _, err := Resize([]byte(`<svg width="10"`), 10, 10, true); err == nil {
+		t.Error("Resize of a truncated document did not fail")
+	}
+}
@@ -0,0 +1,34 @@
+package svg
+
+import "math"
+
+// Rounding is the policy for rounding half units when centering with integer coordinates
+type Rounding int
+
+const (
+	// TruncateTowardTopLeft halves sizes with integer division, as Go does
+	TruncateTowardTopLeft Rounding = iota
+	// NearestEven rounds half units to the nearest even number
+	NearestEven
+	// HalfUp rounds half units up
+	HalfUp
+)
+
+// SetRounding sets the rounding policy of the center based helpers, such as CenterRect,
+// and of CenterOffset. The default is TruncateTowardTopLeft.
+func (svg *SVG) SetRounding(r Rounding) { svg.rounding = r }
+
+// CenterOffset returns the offset at which an extent of size is centered within total,
+// rounded according to the rounding policy
+func (svg *SVG) CenterOffset(total, size int) int { return half(total-size, svg.rounding) }
+
+// half returns half of n, rounded according to r
+func half(n int, r Rounding) int {
+	switch r {
+	case NearestEven:
+		return int(math.RoundToEven(float64(n) / 2))
+	case HalfUp:
+		return int(math.Floor(float64(n)/2 + 0.5))
+	}
+	return n / 2
+}
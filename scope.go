@@ -0,0 +1,95 @@
+package svg
+
+import "fmt"
+
+// scoped opens a container element, runs fn, then ends the element.
+// The element is ended even if fn panics, before the panic continues.
+func (svg *SVG) scoped(open func(), fn func(), end func()) {
+	open()
+	defer end()
+	fn()
+}
+
+// WithGroup runs fn inside a group with the attributes attrs
+func (svg *SVG) WithGroup(attrs string, fn func()) {
+	svg.scoped(func() { svg.Group(attrs) }, fn, svg.Gend)
+}
+
+// WithClip runs fn inside a clip path with the specified id
+func (svg *SVG) WithClip(id string, fn func()) {
+	unlock := svg.lock()
+	attr := svg.idattr(id)
+	unlock()
+	svg.scoped(func() { svg.ClipPath(attr) }, fn, svg.ClipEnd)
+}
+
+// WithMask runs fn inside a mask with the specified id and dimension
+func (svg *SVG) WithMask(id string, x, y, w, h int, fn func()) {
+	svg.scoped(func() { svg.Mask(id, x, y, w, h) }, fn, svg.MaskEnd)
+}
+
+// WithDefs runs fn inside a definition block
+func (svg *SVG) WithDefs(fn func()) {
+	svg.scoped(svg.Def, fn, svg.DefEnd)
+}
+
+// Defs calls fn with the canvas inside a definition block, ended even if fn panics
+func (svg *SVG) Defs(fn func(*SVG)) {
+	svg.scoped(svg.Def, func() { fn(svg) }, svg.DefEnd)
+}
+
+// GroupFn calls fn with the canvas inside a group with optional style, ended even if fn panics
+func (svg *SVG) GroupFn(style string, fn func(*SVG)) {
+	svg.scoped(func() { svg.Group(style) }, func() { fn(svg) }, svg.Gend)
+}
+
+// MaskFn calls fn with the canvas inside a mask with the specified id and dimension, ended even if fn panics
+func (svg *SVG) MaskFn(id string, x, y, w, h int, fn func(*SVG)) {
+	svg.scoped(func() { svg.Mask(id, x, y, w, h) }, func() { fn(svg) }, svg.MaskEnd)
+}
+
+// indefs runs fn inside a definition block, or directly if one is already open
+func (svg *SVG) indefs(fn func()) {
+	if svg.InDefs() {
+		fn()
+		return
+	}
+	svg.WithDefs(fn)
+}
+
+// InDefs determines if a definition block is open
+func (svg *SVG) InDefs() bool {
+	defer svg.lock()()
+	for _, tag := range svg.open {
+		if tag == "defs" {
+			return true
+		}
+	}
+	return false
+}
+
+// WithFilter runs fn inside a filter with the specified id
+func (svg *SVG) WithFilter(id string, fn func()) {
+	svg.scoped(func() { svg.Filter(id) }, fn, svg.Fend)
+}
+
+// WithMarker runs fn inside a marker with the specified id, reference point and dimension
+func (svg *SVG) WithMarker(id string, x, y, width, height int, fn func()) {
+	svg.scoped(func() { svg.Marker(id, x, y, width, height) }, fn, svg.MarkerEnd)
+}
+
+// WithPattern runs fn inside a pattern with the specified id, dimension and units ("user" or "obj")
+func (svg *SVG) WithPattern(id string, x, y, width, height int, putype string, fn func()) {
+	svg.scoped(func() { svg.Pattern(id, x, y, width, height, putype) }, fn, svg.PatternEnd)
+}
+
+// RoundedPanel draws a panel at x,y with dimension w,h and corner radius r, with the background
+// styled by style, then runs draw inside a group clipped to the panel's rounded corners
+func (svg *SVG) RoundedPanel(x, y, w, h, r int, style string, draw func(*SVG)) {
+	unlock := svg.lock()
+	clip := svg.uid("panel")
+	unlock()
+	svg.Roundrect(x, y, w, h, r, r, style)
+	svg.WithClip(clip, func() { svg.Roundrect(x, y, w, h, r, r) })
+	svg.scoped(func() { svg.Group(fmt.Sprintf(`clip-path="url(#%s)"`, clip)) }, func() { draw(svg) }, svg.Gend)
+}
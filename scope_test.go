@@ -0,0 +1,83 @@
+package svg
+
+import (
+	"strings"
+	"testing"
+)
+
+// panicking draws with draw, which should panic with "boom", and returns the document so far
+func panicking(t *testing.T, draw func(*SVG)) string {
+	t.Helper()
+	canvas := NewBuffer()
+	canvas.Start(100, 100)
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("recovered %v, want boom", r)
+			}
+		}()
+		draw(canvas)
+	}()
+	if open := canvas.OpenElements(); len(open) != 0 {
+		t.Errorf("%q left open after the panic", open)
+	}
+	doc := canvas.String()
+	canvas.End()
+	if err := canvas.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	wellformed(t, canvas.String())
+	return doc
+}
+
+func TestScopedPanic(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		draw func(*SVG)
+		want string
+	}{
+		{"Defs", func(canvas *SVG) {
+			canvas.Defs(func(c *SVG) { c.Rect(0, 0, 1, 1, `id="dot"`); panic("boom") })
+		}, `<rect x="0" y="0" width="1" height="1" id="dot"/>` + "\n</defs>\n"},
+		{"GroupFn", func(canvas *SVG) {
+			canvas.GroupFn("fill:red", func(c *SVG) { c.Circle(1, 1, 1); panic("boom") })
+		}, `<circle cx="1" cy="1" r="1"/>` + "\n</g>\n"},
+		{"MaskFn", func(canvas *SVG) {
+			canvas.MaskFn("m", 0, 0, 10, 10, func(c *SVG) { c.Rect(0, 0, 10, 10); panic("boom") })
+		}, `<rect x="0" y="0" width="10" height="10"/>` + "\n</mask>\n"},
+		{"nested", func(canvas *SVG) {
+			canvas.GroupFn("fill:red", func(c *SVG) {
+				c.MaskFn("m", 0, 0, 10, 10, func(c *SVG) {
+					c.Defs(func(c *SVG) { c.Circle(1, 1, 1, `id="dot"`); panic("boom") })
+				})
+			})
+		}, `<circle cx="1" cy="1" r="1" id="dot"/>` + "\n</defs>\n</mask>\n</g>\n"},
+	} {
+		doc := panicking(t, c.draw)
+		if !strings.HasSuffix(doc, c.want) {
+			t.Errorf("%s: drew\n%s\nwant it to end with\n%s", c.name, doc, c.want)
+		}
+	}
+}
+
+func TestScopedNested(t *testing.T) {
+	doc := render(t, func(canvas *SVG) {
+		canvas.GroupFn("fill:red", func(c *SVG) {
+			c.Defs(func(c *SVG) { c.Circle(1, 1, 1, `id="dot"`) })
+			c.GroupFn("stroke:blue", func(c *SVG) {
+				c.MaskFn("m", 0, 0, 10, 10, func(c *SVG) { c.Rect(0, 0, 10, 10) })
+				c.Use(5, 5, "#dot")
+			})
+		})
+	})
+	if depth, n := defsdepth(t, doc); depth != 1 || n != 1 {
+		t.Errorf("%d definition blocks, nested %d deep, in\n%s", n, depth, doc)
+	}
+	want := "</defs>\n<g style=\"stroke:blue\">\n<mask id=\"m\""
+	if !strings.Contains(doc, want) {
+		t.Errorf("%q missing from\n%s", want, doc)
+	}
+	if !strings.Contains(doc, "</mask>\n"+`<use x="5" y="5" xlink:href="#dot"/>`+"\n</g>\n</g>\n</svg>") {
+		t.Errorf("groups not ended in order in\n%s", doc)
+	}
+}
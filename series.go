@@ -0,0 +1,119 @@
+package svg
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Series is a data series drawn by SeriesGroup, with its class and assigned color
+type Series struct {
+	Name, Class, Color string
+}
+
+// LegendOpts specifies the layout of a legend. Zero values give defaults: a column of
+// legendswatch sized swatches, with text widths estimated from the number of characters.
+type LegendOpts struct {
+	Swatch     int              // size of the square swatch of each entry
+	Gap        int              // space between entries
+	Horizontal bool             // lay the entries out in a row, rather than a column
+	TextStyle  string           // style of the text
+	Measure    func(string) int // width of text in the text style, for rows
+}
+
+const legendswatch = 12
+
+// SeriesGroup draws the series named by names, within a group. Each series is given a class,
+// derived from its name, with its fill and stroke set to a color from palette by a style sheet;
+// draw is called for each series in turn with its class attribute. A palette shorter than
+// the series is repeated, with a warning. The series are recorded for LegendFromSeries.
+func (svg *SVG) SeriesGroup(names []string, palette []string, draw func(i int, name, style string)) {
+	unlock := svg.lock()
+	series := make([]Series, len(names))
+	classes := make(map[string]bool)
+	var rules []string
+	for i, name := range names {
+		class := seriesclass(name, i)
+		for n := 2; classes[class]; n++ {
+			class = fmt.Sprintf("%s-%d", seriesclass(name, i), n)
+		}
+		classes[class] = true
+		series[i] = Series{Name: name, Class: class}
+		if len(palette) == 0 {
+			continue
+		}
+		color := palette[i%len(palette)]
+		if !ValidColor(color) {
+			svg.warn("invalid series color %q omitted", color)
+			continue
+		}
+		series[i].Color = color
+		rules = append(rules, fmt.Sprintf(".%s{fill:%s;stroke:%s}", class, color, color))
+	}
+	switch {
+	case len(palette) == 0 && len(names) > 0:
+		svg.warn("no palette for %d series", len(names))
+	case len(palette) < len(names):
+		svg.warn("palette of %d colors repeated for %d series", len(palette), len(names))
+	}
+	svg.series = series
+	unlock()
+	svg.Group()
+	defer svg.Gend()
+	if len(rules) > 0 {
+		svg.Style("text/css", rules...)
+	}
+	for i, s := range series {
+		draw(i, s.Name, `class="`+s.Class+`"`)
+	}
+}
+
+// LegendFromSeries draws a legend at x,y for the series drawn by the last SeriesGroup:
+// a swatch in the class of each series, followed by its name.
+func (svg *SVG) LegendFromSeries(x, y int, opts LegendOpts) {
+	unlock := svg.lock()
+	series := svg.series
+	unlock()
+	sw := opts.Swatch
+	if sw <= 0 {
+		sw = legendswatch
+	}
+	gap := opts.Gap
+	if gap <= 0 {
+		gap = sw / 2
+	}
+	measure := opts.Measure
+	if measure == nil {
+		measure = func(s string) int { return len(graphemes(s)) * sw * 3 / 5 }
+	}
+	svg.Group(`class="legend"`)
+	defer svg.Gend()
+	for _, s := range series {
+		svg.Rect(x, y, sw, sw, `class="`+s.Class+`"`)
+		svg.Text(x+sw+labelgap, y+sw/2, s.Name, "dominant-baseline:central", opts.TextStyle)
+		if opts.Horizontal {
+			x += sw + labelgap + measure(s.Name) + gap
+		} else {
+			y += sw + gap
+		}
+	}
+}
+
+// seriesclass returns the class of the series named name, at index i: the name in lower case,
+// with runs of characters other than letters and digits replaced by hyphens
+func seriesclass(name string, i int) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "-"):
+			b.WriteByte('-')
+		}
+	}
+	class := strings.TrimSuffix(b.String(), "-")
+	if class == "" {
+		return fmt.Sprintf("series-%d", i)
+	}
+	return "series-" + class
+}
@@ -24,25 +24,88 @@ package svg
 //
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 
 	"encoding/xml"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 )
 
 // SVG defines the location of the generated SVG
 type SVG struct {
-	Writer io.Writer
+	Writer    io.Writer
+	buffer    *bufio.Writer
+	doc       *bytes.Buffer
+	profile   Profile
+	warnings  []string
+	blocks    map[string]int
+	layers    []layer
+	ids       *int64
+	mu        *sync.Mutex
+	contrast  *HighContrast
+	state     state
+	nbytes    int64
+	elements  map[string]int
+	strict    bool
+	lenient   bool
+	err       error
+	open      []string
+	stray     []string
+	seeker    io.WriteSeeker
+	size      rootsize
+	preamble  *Preamble
+	openids   []string
+	texts     []TextItem
+	span      TextItem
+	tee       *tee
+	detail    int
+	hidden    int // depth of foreign objects blocked by the profile, whose content is not written
+	current   string
+	svg2      bool
+	fragment  bool
+	precision struct {
+		places int
+		set    bool
+	}
+
+	descriptions  []description
+	namespaces    [][2]string
+	series        []Series
+	pis           []string
+	rounding      Rounding
+	metrics       *FontMetrics
+	nonce         string
+	placeholders  placeholders
+	generator     *generator
+	clock         func() time.Time
+	deterministic bool
 }
 
-// Offcolor defines the offset and color for gradients
+// Offcolor defines the offset and color for gradients; negative opacities are omitted
 type Offcolor struct {
 	Offset  uint8
 	Color   string
 	Opacity float64
 }
 
+// Stop defines the offset, as a percentage that may be fractional, and color for gradients;
+// negative opacities are omitted
+type Stop struct {
+	Offset  float64
+	Color   string
+	Opacity float64
+}
+
 // Filterspec defines the specification of SVG filters
 type Filterspec struct {
 	In, In2, Result string
@@ -55,31 +118,369 @@ const (
 	svgns      = `
      xmlns="http://www.w3.org/2000/svg"
      xmlns:xlink="http://www.w3.org/1999/xlink">`
+	svg2ns = `
+     xmlns="http://www.w3.org/2000/svg">`
 	vbfmt = `viewBox="%d %d %d %d"`
 
 	emptyclose = "/>\n"
+
+	lenientsize = 100
+)
+
+// Stats describes the output of a canvas: the number of bytes written,
+// and the number of each type of element, keyed by element name
+type Stats struct {
+	Bytes    int64
+	Elements map[string]int
+}
+
+// state is the lifecycle state of a document
+type state int
+
+const (
+	unstarted state = iota
+	started
+	ended
+)
+
+var (
+	// ErrNilWriter is latched by a canvas made with a nil io.Writer
+	ErrNilWriter = errors.New("svg: nil io.Writer")
+	// ErrNotStarted is latched when drawing, or ending the document, before Start
+	ErrNotStarted = errors.New("svg: document not started")
+	// ErrStarted is latched when starting a document that has already started
+	ErrStarted = errors.New("svg: document already started")
+	// ErrEnded is latched when drawing after End
+	ErrEnded = errors.New("svg: document already ended")
+	// ErrMismatchedPoints is latched when the x and y coordinates of a shape differ in length
+	ErrMismatchedPoints = errors.New("svg: x and y coordinates differ in length")
+	// ErrInvalidID is latched in strict mode by an id that is not a valid XML name
+	ErrInvalidID = errors.New("svg: invalid id")
+	// ErrUnsafeLink is latched in strict mode by a link with a scheme other than http, https or data
+	ErrUnsafeLink = errors.New("svg: link with unsafe scheme")
+	// ErrInvalidColor is latched in strict mode by a gradient stop with an invalid color
+	ErrInvalidColor = errors.New("svg: invalid color")
+	// ErrInvalidChar is latched in strict mode by text or attributes containing characters not allowed in XML,
+	// which are replaced by U+FFFD
+	ErrInvalidChar = errors.New("svg: invalid XML character")
+	// ErrInvalidPath is returned by PathChecked, and latched by Path in strict mode, for invalid path data
+	ErrInvalidPath = errors.New("svg: invalid path data")
+	// ErrRequiresBuffer is returned by operations that need a canvas made with NewBuffer
+	ErrRequiresBuffer = errors.New("svg: canvas is not backed by a buffer")
 )
 
+// LifecycleError reports a call made out of order in the life of a document:
+// Err is ErrNotStarted, ErrStarted or ErrEnded, and Op names the element or method called.
+type LifecycleError struct {
+	Op  string
+	Err error
+}
+
+func (e *LifecycleError) Error() string { return e.Err.Error() + " (" + e.Op + ")" }
+
+// Unwrap returns the underlying error, so that errors.Is(err, ErrNotStarted) and the like hold
+func (e *LifecycleError) Unwrap() error { return e.Err }
+
+// UnbalancedError reports container elements that were left open at the end of the document,
+// and end methods that did not match the innermost open container.
+type UnbalancedError struct {
+	Open  []string // containers left open, outermost first
+	Stray []string // closing tags without a matching open container, in order
+}
+
+func (e *UnbalancedError) Error() string {
+	var msg []string
+	if len(e.Open) > 0 {
+		msg = append(msg, "unclosed "+strings.Join(e.Open, ", "))
+	}
+	if len(e.Stray) > 0 {
+		msg = append(msg, "unmatched end of "+strings.Join(e.Stray, ", "))
+	}
+	return "svg: " + strings.Join(msg, "; ")
+}
+
 // New is the SVG constructor, specifying the io.Writer where the generated SVG is written.
-func New(w io.Writer) *SVG { return &SVG{w} }
+// If w is nil, nothing is written and the canvas reports ErrNilWriter from Err.
+func New(w io.Writer) *SVG { return NewWithOptions(w, Options{}) }
+
+// NewBuffered is the SVG constructor, buffering the generated SVG in chunks of size bytes
+// (or a default size, if size is not positive) before writing to w. The buffer is flushed by End,
+// or explicitly with Flush.
+func NewBuffered(w io.Writer, size int) *SVG {
+	if size <= 0 {
+		size = defaultbuffer
+	}
+	return NewWithOptions(w, Options{Buffer: size})
+}
+
+// NewSafe is the SVG constructor for canvases shared by several goroutines.
+// Each element is written as a whole, so elements drawn concurrently are never torn,
+// although they may be interleaved in any order. Containers begun and ended with separate calls
+// (groups, definitions and the like) are not protected, and may collect elements drawn by other
+// goroutines in the meantime; use AtomicGroup to draw a group as a whole.
+func NewSafe(w io.Writer) *SVG {
+	svg := New(w)
+	svg.ids = new(int64)
+	svg.mu = new(sync.Mutex)
+	return svg
+}
+
+// NewBuffer is the SVG constructor, accumulating the generated SVG in an internal buffer,
+// retrieved with String or Bytes, or written out with WriteTo.
+func NewBuffer() *SVG {
+	b := new(bytes.Buffer)
+	return &SVG{Writer: b, doc: b}
+}
+
+// String returns the SVG generated so far on a canvas made with NewBuffer,
+// and the empty string otherwise.
+func (svg *SVG) String() string {
+	if svg.doc == nil {
+		return ""
+	}
+	return svg.doc.String()
+}
+
+// Bytes returns the SVG generated so far on a canvas made with NewBuffer, and nil otherwise.
+// The slice is only valid until the next drawing operation.
+func (svg *SVG) Bytes() []byte {
+	if svg.doc == nil {
+		return nil
+	}
+	return svg.doc.Bytes()
+}
+
+// WriteTo writes the SVG generated so far on a canvas made with NewBuffer to w,
+// implementing io.WriterTo. The internal buffer is left intact.
+func (svg *SVG) WriteTo(w io.Writer) (int64, error) {
+	if !svg.Capabilities().Buffered {
+		return 0, ErrRequiresBuffer
+	}
+	n, err := w.Write(svg.doc.Bytes())
+	return int64(n), err
+}
+
+// Flush writes any buffered output to the underlying io.Writer.
+// Canvases made with New flush their writer, if it buffers its output, and otherwise Flush does nothing.
+func (svg *SVG) Flush() error {
+	defer svg.lock()()
+	return svg.flush()
+}
+
+// flush writes any buffered output, recording any error
+func (svg *SVG) flush() error {
+	f, ok := svg.Writer.(flusher)
+	if !ok {
+		return nil
+	}
+	err := f.Flush()
+	svg.latch(err)
+	return err
+}
 
 func (svg *SVG) print(a ...interface{}) (n int, errno error) {
-	return fmt.Fprint(svg.Writer, a...)
+	if !svg.writable() {
+		return 0, svg.err
+	}
+	n, errno = fmt.Fprint(svg.Writer, a...)
+	svg.nbytes += int64(n)
+	svg.latch(errno)
+	return
 }
 
 func (svg *SVG) println(a ...interface{}) (n int, errno error) {
-	return fmt.Fprintln(svg.Writer, a...)
+	if !svg.writable() {
+		return 0, svg.err
+	}
+	n, errno = fmt.Fprintln(svg.Writer, a...)
+	svg.nbytes += int64(n)
+	svg.latch(errno)
+	return
 }
 
 func (svg *SVG) printf(format string, a ...interface{}) (n int, errno error) {
-	return fmt.Fprintf(svg.Writer, format, a...)
+	if !svg.writable() {
+		return 0, svg.err
+	}
+	n, errno = io.WriteString(svg.Writer, tagspace(fmt.Sprintf(format, a...)))
+	svg.nbytes += int64(n)
+	svg.latch(errno)
+	return
+}
+
+// tagspace normalizes the whitespace in the tags of s, so that attributes are separated by
+// single spaces, with none before the tag closes. Attribute values and content are unchanged.
+func tagspace(s string) string {
+	if !strings.Contains(s, "<") {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	intag, space := false, false
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case !intag:
+			intag = c == '<' && i+1 < len(s) && (s[i+1] == '/' || s[i+1] == '_' || s[i+1] == ':' || s[i+1] >= 0x80 ||
+				('a' <= s[i+1]|0x20 && s[i+1]|0x20 <= 'z'))
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == ' ':
+			space = true
+			continue
+		case c == '>' || c == '/' && i+1 < len(s) && s[i+1] == '>' || c == '\n':
+			space, intag = false, c != '>'
+		case c == '"' || c == '\'':
+			quote = c
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteByte(c)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// escape writes s, escaped as XML character data
+func (svg *SVG) escape(s string) {
+	svg.checkchars(s)
+	svg.print(xmlescape(s))
+}
+
+// xmlescape returns s, escaped as XML character data
+func xmlescape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// idattr returns the id attribute for id, escaped. In strict mode, an id that is not
+// a valid XML name, and so cannot be referenced, latches ErrInvalidID.
+func (svg *SVG) idattr(id string) string {
+	if svg.strict && !xmlname(id) {
+		svg.latch(ErrInvalidID)
+	}
+	return `id="` + xmlescape(id) + `"`
+}
+
+// writable determines if output can be written, checking for misuse of the canvas
+func (svg *SVG) writable() bool {
+	if svg.Writer == nil {
+		svg.latch(ErrNilWriter)
+		return false
+	}
+	if svg.hidden > 0 {
+		return false
+	}
+	switch svg.state {
+	case unstarted:
+		if svg.strict {
+			svg.latch(ErrNotStarted)
+		}
+	case ended:
+		svg.latch(ErrEnded)
+		return false
+	}
+	return true
+}
+
+// Stats returns the number of bytes and elements written so far
+func (svg *SVG) Stats() Stats {
+	defer svg.lock()()
+	e := make(map[string]int, len(svg.elements))
+	for k, v := range svg.elements {
+		e[k] = v
+	}
+	return Stats{Bytes: svg.nbytes, Elements: e}
+}
+
+// lock acquires the lock of a canvas made with NewSafe, returning the function that releases it
+func (svg *SVG) lock() func() {
+	if svg.tee != nil {
+		svg.tee.reset()
+	}
+	if svg.mu == nil {
+		return func() {}
+	}
+	svg.mu.Lock()
+	return svg.mu.Unlock
+}
+
+// count records the writing of an element
+func (svg *SVG) count(tag string) {
+	svg.started(tag)
+	if svg.elements == nil {
+		svg.elements = make(map[string]int)
+	}
+	svg.elements[tag]++
+	svg.current = tag
+	if svg.tee != nil {
+		svg.tee.element(tag, svg.detail)
+	}
+}
+
+// started checks that the document has started, and has not ended, before writing op,
+// starting it in lenient mode
+func (svg *SVG) started(op string) {
+	switch svg.state {
+	case unstarted:
+		if !svg.lenient {
+			svg.latch(&LifecycleError{Op: op, Err: ErrNotStarted})
+			break
+		}
+		svg.begin(fmt.Sprintf(svginitfmt, svg.top(), lenientsize, "%", lenientsize, "%"), nil)
+	case ended:
+		svg.latch(&LifecycleError{Op: op, Err: ErrEnded})
+	}
+}
+
+// SetLenient turns lenient mode on or off. In lenient mode, drawing before Start
+// begins the document, at the full size of its container, instead of latching an error,
+// and End closes any containers left open, with a warning.
+func (svg *SVG) SetLenient(on bool) { svg.lenient = on }
+
+// Err returns the first error encountered generating the document
+func (svg *SVG) Err() error {
+	defer svg.lock()()
+	return svg.err
+}
+
+// SetStrict turns strict checking of the document on or off.
+// In strict mode, misuse such as drawing before Start is reported by Err,
+// as are ids that are not valid XML names; elements linking to schemes other than
+// http, https and data are omitted, as are paths with invalid path data,
+// and invalid colors are reported by Warnings (and, for gradient stops, by Err).
+func (svg *SVG) SetStrict(on bool) { svg.strict = on }
+
+// latch records the first error encountered generating the document
+func (svg *SVG) latch(err error) {
+	if svg.err == nil {
+		svg.err = err
+	}
 }
 
 func (svg *SVG) genattr(ns []string) {
 	for _, v := range ns {
-		svg.printf("\n     %s", v)
+		svg.printf("\n     %s", strings.TrimPrefix(v, rawmark))
+	}
+	for _, ns := range svg.namespaces {
+		svg.printf("\n     xmlns:%s=\"%s\"", ns[0], attrescape(ns[1]))
+	}
+	switch {
+	case svg.fragment:
+		svg.println(">")
+	case svg.svg2:
+		svg.println(svg2ns)
+	default:
+		svg.println(svgns)
 	}
-	svg.println(svgns)
 }
 
 // Structure, Metadata, Scripting, Style, Transformation, and Links
@@ -88,22 +489,35 @@ func (svg *SVG) genattr(ns []string) {
 // Other attributes may be optionally added, for example viewbox or additional namespaces
 // Standard Reference: http://www.w3.org/TR/SVG11/struct.html#SVGElement
 func (svg *SVG) Start(w int, h int, ns ...string) {
-	svg.printf(svginitfmt, svgtop, w, "", h, "")
-	svg.genattr(ns)
+	defer svg.lock()()
+	svg.begin(fmt.Sprintf(svginitfmt, svg.top(), w, "", h, ""), ns)
+}
+
+// Startstyled begins the SVG document with the width w and height h, and the root element
+// styled by style, for example a background color. Other attributes may be optionally added, as for Start.
+func (svg *SVG) Startstyled(w int, h int, style string, ns ...string) {
+	svg.Start(w, h, append([]string{`style="` + attrescape(style) + `"`}, ns...)...)
+}
+
+// Startfragment begins an SVG fragment, for inclusion in an HTML document, with the width w
+// and height h. Other attributes may be optionally added, as for Start.
+func (svg *SVG) Startfragment(w int, h int, s ...string) {
+	svg.SetFragment(true)
+	svg.Start(w, h, s...)
 }
 
 // Startunit begins the SVG document, with width and height in the specified units
 // Other attributes may be optionally added, for example viewbox or additional namespaces
 func (svg *SVG) Startunit(w int, h int, unit string, ns ...string) {
-	svg.printf(svginitfmt, svgtop, w, unit, h, unit)
-	svg.genattr(ns)
+	defer svg.lock()()
+	svg.begin(fmt.Sprintf(svginitfmt, svg.top(), w, unit, h, unit), ns)
 }
 
 // Startpercent begins the SVG document, with width and height as percentages
 // Other attributes may be optionally added, for example viewbox or additional namespaces
 func (svg *SVG) Startpercent(w int, h int, ns ...string) {
-	svg.printf(svginitfmt, svgtop, w, "%", h, "%")
-	svg.genattr(ns)
+	defer svg.lock()()
+	svg.begin(fmt.Sprintf(svginitfmt, svg.top(), w, "%", h, "%"), ns)
 }
 
 // Startview begins the SVG document, with the specified width, height, and viewbox
@@ -119,53 +533,199 @@ func (svg *SVG) StartviewUnit(w, h int, unit string, minx, miny, vw, vh int) {
 
 // Startraw begins the SVG document, passing arbitrary attributes
 func (svg *SVG) Startraw(ns ...string) {
-	svg.printf(svgtop)
+	defer svg.lock()()
+	svg.begin(svg.top(), ns)
+}
+
+// StartrawAttrs begins the SVG document with the attributes in attrs, written in order of name,
+// with escaped values. Invalid names are omitted, with a warning.
+func (svg *SVG) StartrawAttrs(attrs map[string]string) {
+	defer svg.lock()()
+	svg.begin(svg.top(), svg.mapattrs(attrs))
+}
+
+// begin starts the document with the svg element beginning top, and attributes ns.
+// Starting a document that has already started latches an error, and is ignored.
+func (svg *SVG) begin(top string, ns []string) {
+	if svg.state != unstarted {
+		svg.latch(&LifecycleError{Op: "Start", Err: ErrStarted})
+		return
+	}
+	svg.state = started
+	svg.count("svg")
+	svg.print(svg.reservesize(top))
 	svg.genattr(ns)
+	svg.generated(GeneratorAtStart)
+}
+
+// End the SVG document, flushing any buffered output.
+// Ending a document that has not started latches an error; ending it again is ignored, with a warning.
+func (svg *SVG) End() {
+	defer svg.lock()()
+	switch svg.state {
+	case unstarted:
+		svg.latch(&LifecycleError{Op: "End", Err: ErrNotStarted})
+		return
+	case ended:
+		svg.warn("End called after the document ended")
+		return
+	}
+	if svg.lenient && len(svg.open) > 0 {
+		svg.warn("End closed %d open elements", len(svg.open))
+		svg.closeall()
+	}
+	svg.blockreport()
+	svg.describe()
+	svg.generated(GeneratorAtEnd)
+	svg.println("</svg>")
+	svg.state = ended
+	svg.flush()
+	svg.patchsize()
+}
+
+// Close ends the document, if it was started and has not already ended, flushes any buffered output,
+// and returns the first error encountered writing the document. Repeated calls have no further effect,
+// so that Close may be deferred immediately after New.
+func (svg *SVG) Close() error {
+	if svg.state == started {
+		svg.End()
+	}
+	return svg.Err()
+}
+
+// EndChecked ends the SVG document, reporting any containers (groups, nested svg elements, definitions,
+// clip paths, masks, markers, symbols, patterns, filters, links and spanned text) left open, or closed without being opened.
+func (svg *SVG) EndChecked() error {
+	svg.End()
+	defer svg.lock()()
+	if len(svg.open) == 0 && len(svg.stray) == 0 {
+		return nil
+	}
+	return &UnbalancedError{Open: append([]string(nil), svg.open...), Stray: append([]string(nil), svg.stray...)}
+}
+
+// CloseAll ends every open container element, innermost first, leaving the document itself open
+func (svg *SVG) CloseAll() {
+	defer svg.lock()()
+	svg.closeall()
 }
 
-// End the SVG document
-func (svg *SVG) End() { svg.println("</svg>") }
+// closeall ends the open container elements
+func (svg *SVG) closeall() {
+	for n := len(svg.open); n > 0; n = len(svg.open) {
+		tag := svg.open[n-1]
+		svg.pop(tag)
+		svg.println("</" + tag + ">")
+	}
+}
+
+// OpenElements returns the names of the open container elements, outermost first
+func (svg *SVG) OpenElements() []string {
+	defer svg.lock()()
+	return append([]string(nil), svg.open...)
+}
 
 // linkembed defines an element with a specified type,
 // (for example "application/javascript", or "text/css").
 // if the first variadic argument is a link, use only the link reference.
 // Otherwise, treat those arguments as the text of the script (marked up as CDATA).
-// if no data is specified, just close the element
-func (svg *SVG) linkembed(tag string, scriptype string, data ...string) {
-	svg.printf(`<%s type="%s"`, tag, scriptype)
+// if no data is specified, just close the element. A non-empty nonce is added as an attribute.
+func (svg *SVG) linkembed(tag string, scriptype string, nonce string, data ...string) {
+	if len(data) == 1 && islink(data[0]) {
+		svg.linkref(tag, scriptype, nonce, data[0])
+		return
+	}
+	svg.checkchars(data...)
+	svg.embedtag(tag, scriptype, nonce)
 	switch {
-	case len(data) == 1 && islink(data[0]):
-		svg.printf(" %s/>\n", href(data[0]))
-
 	case len(data) > 0:
 		svg.printf(">\n<![CDATA[\n")
-		for _, v := range data {
-			svg.println(v)
-		}
-		svg.printf("]]>\n</%s>\n", tag)
+		svg.cdatachunks(data)
+		svg.printf("\n]]>\n</%s>\n", tag)
 
 	default:
 		svg.println(`/>`)
 	}
 }
 
+// linkref defines an element with a specified type, referring to link
+func (svg *SVG) linkref(tag string, scriptype string, nonce string, link string) {
+	svg.embedtag(tag, scriptype, nonce)
+	if svg.unsafelink(link) {
+		link = ""
+	}
+	svg.printf(" %s/>\n", svg.href(link))
+}
+
+// embedtag begins a script or style element, with a nonce attribute if nonce is not empty
+func (svg *SVG) embedtag(tag string, scriptype string, nonce string) {
+	svg.count(tag)
+	svg.printf(`<%s type="%s"`, tag, scriptype)
+	if nonce != "" {
+		svg.printf(` nonce="%s"`, attrescape(nonce))
+	}
+}
+
 // Script defines a script with a specified type, (for example "application/javascript").
 func (svg *SVG) Script(scriptype string, data ...string) {
-	svg.linkembed("script", scriptype, data...)
+	defer svg.lock()()
+	if svg.blocked("script") {
+		return
+	}
+	svg.linkembed("script", scriptype, svg.nonce, data...)
+}
+
+// ScriptNonce defines a script like Script, carrying the nonce required by a content security policy
+func (svg *SVG) ScriptNonce(scriptype, nonce string, data ...string) {
+	defer svg.lock()()
+	if svg.blocked("script") {
+		return
+	}
+	svg.linkembed("script", scriptype, nonce, data...)
+}
+
+// ScriptLink defines a script with a specified type, referring to link,
+// which is not mistaken for the text of a script whatever its form
+func (svg *SVG) ScriptLink(scriptype, link string) {
+	defer svg.lock()()
+	if svg.blocked("script") {
+		return
+	}
+	svg.linkref("script", scriptype, svg.nonce, link)
 }
 
+// SetNonce sets the content security policy nonce carried by scripts defined by Script
+// and ScriptLink, and by helpers that add scripts, such as LayerControls
+func (svg *SVG) SetNonce(nonce string) { svg.nonce = nonce }
+
 // Style defines the specified style (for example "text/css")
 func (svg *SVG) Style(scriptype string, data ...string) {
-	svg.linkembed("style", scriptype, data...)
+	defer svg.lock()()
+	svg.linkembed("style", scriptype, "", data...)
+}
+
+// StyleLink defines a style with a specified type, referring to link,
+// which is not mistaken for the text of a style whatever its form
+func (svg *SVG) StyleLink(scriptype, link string) {
+	defer svg.lock()()
+	svg.linkref("style", scriptype, "", link)
 }
 
 // Gstyle begins a group, with the specified style.
 // Standard Reference: http://www.w3.org/TR/SVG11/struct.html#GElement
-func (svg *SVG) Gstyle(s string) { svg.println(group("style", s)) }
+func (svg *SVG) Gstyle(s string) {
+	defer svg.lock()()
+	svg.count("g")
+	svg.push("g")
+	svg.println(group("style", s))
+}
 
 // Gtransform begins a group, with the specified transform
 // Standard Reference: http://www.w3.org/TR/SVG11/coords.html#TransformAttribute
 func (svg *SVG) Gtransform(s string) {
+	defer svg.lock()()
+	svg.count("g")
+	svg.push("g")
 	svg.printf(`<g transform="%s">`, s)
 	svg.println("")
 }
@@ -209,134 +769,459 @@ func (svg *SVG) RotateTranslate(x, y int, r float64) {
 }
 
 // Group begins a group with arbitrary attributes
-func (svg *SVG) Group(s ...string) { svg.printf("<g %s\n", endstyle(s, `>`)) }
+func (svg *SVG) Group(s ...string) {
+	defer svg.lock()()
+	svg.count("g")
+	svg.push("g")
+	svg.printf("<g %s\n", svg.endstyle(s, `>`))
+	svg.openids[len(svg.openids)-1] = groupid(s)
+}
+
+// InnerSVG begins a nested svg element at x,y with dimension w,h, establishing a new viewport,
+// with the coordinates given by the viewbox ("minx miny width height"; empty for none), and optional style.
+// End with InnerSVGEnd.
+// Standard Reference: http://www.w3.org/TR/SVG11/struct.html#SVGElement
+func (svg *SVG) InnerSVG(x, y, w, h int, viewbox string, s ...string) {
+	defer svg.lock()()
+	svg.count("svg")
+	svg.push("svg")
+	vb := ""
+	if viewbox != "" {
+		vb = ` viewBox="` + attrescape(viewbox) + `"`
+	}
+	svg.printf("<svg %s%s %s\n", dim(x, y, w, h), vb, svg.endstyle(s, ">"))
+}
+
+// InnerSVGEnd ends a nested svg element
+func (svg *SVG) InnerSVGEnd() {
+	defer svg.lock()()
+	svg.pop("svg")
+	svg.println(`</svg>`)
+}
+
+// GroupAttrs begins a group with the attributes in attrs, written in order of name,
+// with escaped values. Invalid names are omitted, with a warning.
+func (svg *SVG) GroupAttrs(attrs map[string]string) {
+	unlock := svg.lock()
+	s := svg.mapattrs(attrs)
+	unlock()
+	svg.Group(s...)
+}
 
 // Gid begins a group, with the specified id
 func (svg *SVG) Gid(s string) {
-	svg.print(`<g id="`)
-	xml.Escape(svg.Writer, []byte(s))
-	svg.println(`">`)
+	defer svg.lock()()
+	svg.count("g")
+	svg.push("g")
+	svg.println(`<g ` + svg.idattr(s) + `>`)
+	svg.openids[len(svg.openids)-1] = s
 }
 
+// Gclass begins a group, with the class names, as given by Class
+func (svg *SVG) Gclass(names ...string) { svg.Group(Class(names...)) }
+
 // Gend ends a group (must be paired with Gsttyle, Gtransform, Gid).
-func (svg *SVG) Gend() { svg.println(`</g>`) }
+func (svg *SVG) Gend() {
+	defer svg.lock()()
+	svg.pop("g")
+	svg.println(`</g>`)
+}
 
 // ClipPath defines a clip path
-func (svg *SVG) ClipPath(s ...string) { svg.printf(`<clipPath %s`, endstyle(s, `>`)) }
+func (svg *SVG) ClipPath(s ...string) {
+	defer svg.lock()()
+	svg.count("clipPath")
+	svg.push("clipPath")
+	svg.printf(`<clipPath %s`, svg.endstyle(s, `>`))
+}
 
 // ClipEnd ends a ClipPath
 func (svg *SVG) ClipEnd() {
+	defer svg.lock()()
+	svg.pop("clipPath")
 	svg.println(`</clipPath>`)
 }
 
+// ClipPathUnits defines a clip path with the specified id, and the units of its content
+// ("user" or "obj", or the full attribute values), omitted if empty, or invalid, with a warning
+func (svg *SVG) ClipPathUnits(id string, units string, s ...string) {
+	defer svg.lock()()
+	svg.count("clipPath")
+	svg.push("clipPath")
+	svg.printf(`<clipPath %s%s %s`, svg.idattr(id), svg.unitsopt("clipPathUnits", units), svg.endstyle(s, `>`))
+}
+
+// ClipRect defines, within a definition block, opened if one is not already, a clip path with
+// the specified id of the rectangle at x,y with dimension w,h, and returns the reference to it,
+// for clip-path styles
+func (svg *SVG) ClipRect(id string, x, y, w, h int) string {
+	svg.indefs(func() {
+		svg.ClipPathUnits(id, "")
+		svg.Rect(x, y, w, h)
+		svg.ClipEnd()
+	})
+	return "url(#" + id + ")"
+}
+
+// ClipCircle defines, within a definition block, opened if one is not already, a clip path with
+// the specified id of the circle centered at cx,cy with radius r, and returns the reference to it,
+// for clip-path styles
+func (svg *SVG) ClipCircle(id string, cx, cy, r int) string {
+	svg.indefs(func() {
+		svg.ClipPathUnits(id, "")
+		svg.Circle(cx, cy, r)
+		svg.ClipEnd()
+	})
+	return "url(#" + id + ")"
+}
+
 // Def begins a defintion block.
 // Standard Reference: http://www.w3.org/TR/SVG11/struct.html#DefsElement
-func (svg *SVG) Def() { svg.println(`<defs>`) }
+func (svg *SVG) Def() {
+	defer svg.lock()()
+	svg.count("defs")
+	svg.push("defs")
+	svg.println(`<defs>`)
+}
 
 // DefEnd ends a defintion block.
-func (svg *SVG) DefEnd() { svg.println(`</defs>`) }
+func (svg *SVG) DefEnd() {
+	defer svg.lock()()
+	svg.pop("defs")
+	svg.println(`</defs>`)
+}
 
 // Marker defines a marker
 // Standard reference: http://www.w3.org/TR/SVG11/painting.html#MarkerElement
 func (svg *SVG) Marker(id string, x, y, width, height int, s ...string) {
-	svg.printf(`<marker id="%s" refX="%d" refY="%d" markerWidth="%d" markerHeight="%d" %s`,
-		id, x, y, width, height, endstyle(s, ">\n"))
+	svg.MarkerFull(id, x, y, width, height, "", "", "", s...)
+}
+
+// MarkerFull defines a marker like Marker, with its orientation ("auto", "auto-start-reverse" or an angle),
+// units ("stroke" or "user", or the full attribute values) and viewbox ("minx miny width height").
+// Empty values are omitted, as are invalid orientations and units, with a warning.
+func (svg *SVG) MarkerFull(id string, refx, refy, w, h int, orient, units, viewbox string, s ...string) {
+	defer svg.lock()()
+	svg.count("marker")
+	svg.push("marker")
+	var opt strings.Builder
+	if orient != "" {
+		if markerorient(orient) {
+			fmt.Fprintf(&opt, ` orient="%s"`, orient)
+		} else {
+			svg.warn("invalid marker orientation %q omitted", orient)
+		}
+	}
+	if units != "" {
+		if u := markerunits(units); u != "" {
+			fmt.Fprintf(&opt, ` markerUnits="%s"`, u)
+		} else {
+			svg.warn("invalid marker units %q omitted", units)
+		}
+	}
+	if viewbox != "" {
+		fmt.Fprintf(&opt, ` viewBox="%s"`, attrescape(viewbox))
+	}
+	svg.printf(`<marker %s refX="%d" refY="%d" markerWidth="%d" markerHeight="%d"%s%s`,
+		svg.idattr(id), refx, refy, w, h, opt.String(), svg.endattrs(s, ">\n"))
 }
 
 // MarkerEnd ends a marker
-func (svg *SVG) MarkerEnd() { svg.println(`</marker>`) }
+func (svg *SVG) MarkerEnd() {
+	defer svg.lock()()
+	svg.pop("marker")
+	svg.println(`</marker>`)
+}
+
+// Symbol begins a symbol, a graphic to be placed by Use, with optional style. End with SymbolEnd.
+// Standard reference: http://www.w3.org/TR/SVG11/struct.html#SymbolElement
+func (svg *SVG) Symbol(id string, s ...string) {
+	defer svg.lock()()
+	svg.count("symbol")
+	svg.push("symbol")
+	svg.printf(`<symbol %s%s`, svg.idattr(id), svg.endattrs(s, ">\n"))
+}
+
+// SymbolView begins a symbol, like Symbol, with the viewbox minx, miny, vw, vh
+func (svg *SVG) SymbolView(id string, minx, miny, vw, vh int, s ...string) {
+	svg.Symbol(id, append([]string{fmt.Sprintf(vbfmt, minx, miny, vw, vh)}, s...)...)
+}
+
+// SymbolEnd ends a symbol
+func (svg *SVG) SymbolEnd() {
+	defer svg.lock()()
+	svg.pop("symbol")
+	svg.println(`</symbol>`)
+}
+
+// Switch begins a switch, rendering only the first of its children whose conditions hold,
+// with optional style. End with SwitchEnd.
+// Standard Reference: http://www.w3.org/TR/SVG11/struct.html#SwitchElement
+func (svg *SVG) Switch(s ...string) {
+	defer svg.lock()()
+	svg.count("switch")
+	svg.push("switch")
+	svg.printf("<switch %s\n", svg.endstyle(s, `>`))
+}
+
+// SwitchEnd ends a switch
+func (svg *SVG) SwitchEnd() {
+	defer svg.lock()()
+	svg.pop("switch")
+	svg.println(`</switch>`)
+}
+
+// SwitchLang begins a group within a switch, chosen for the languages lang
+// (a comma separated list such as "en" or "de,de-AT"), with optional style. End with Gend.
+func (svg *SVG) SwitchLang(lang string, s ...string) {
+	svg.GroupIf(SystemLanguage(lang), s...)
+}
 
 // Pattern defines a pattern with the specified dimensions.
 // The putype can be either "user" or "obj", which sets the patternUnits
 // attribute to be either userSpaceOnUse or objectBoundingBox
 // Standard reference: http://www.w3.org/TR/SVG11/pservers.html#Patterns
 func (svg *SVG) Pattern(id string, x, y, width, height int, putype string, s ...string) {
-	puattr := "userSpaceOnUse"
-	if putype != "user" {
-		puattr = "objectBoundingBox"
+	svg.PatternFull(id, float64(x), float64(y), float64(width), float64(height), putype, "", "", s...)
+}
+
+// PatternFull defines a pattern like Pattern, with the units of its content, and a transform.
+// The patternUnits and contentUnits may be "user" or "obj" (or the full attribute values);
+// empty contentUnits and transform are omitted. End with PatternEnd.
+// Standard reference: http://www.w3.org/TR/SVG11/pservers.html#Patterns
+func (svg *SVG) PatternFull(id string, x, y, w, h float64, patternUnits, contentUnits, transform string, s ...string) {
+	defer svg.lock()()
+	svg.count("pattern")
+	svg.push("pattern")
+	svg.printf(`<pattern %s x="%g" y="%g" width="%g" height="%g" patternUnits="%s"`,
+		svg.idattr(id), x, y, w, h, unitsattr(patternUnits))
+	if contentUnits != "" {
+		svg.printf(` patternContentUnits="%s"`, unitsattr(contentUnits))
+	}
+	if transform != "" {
+		svg.printf(` patternTransform="%s"`, attrescape(transform))
 	}
-	svg.printf(`<pattern id="%s" x="%d" y="%d" width="%d" height="%d" patternUnits="%s" %s`,
-		id, x, y, width, height, puattr, endstyle(s, ">\n"))
+	svg.print(svg.endattrs(s, ">\n"))
 }
 
 // PatternEnd ends a marker
-func (svg *SVG) PatternEnd() { svg.println(`</pattern>`) }
+func (svg *SVG) PatternEnd() {
+	defer svg.lock()()
+	svg.pop("pattern")
+	svg.println(`</pattern>`)
+}
 
 // Desc specified the text of the description tag.
 // Standard Reference: http://www.w3.org/TR/SVG11/struct.html#DescElement
-func (svg *SVG) Desc(s string) { svg.tt("desc", s) }
+func (svg *SVG) Desc(s string) {
+	defer svg.lock()()
+	svg.tt("desc", s)
+}
 
 // Title specified the text of the title tag.
 // Standard Reference: http://www.w3.org/TR/SVG11/struct.html#TitleElement
-func (svg *SVG) Title(s string) { svg.tt("title", s) }
+func (svg *SVG) Title(s string) {
+	defer svg.lock()()
+	svg.tt("title", s)
+}
+
+// Comment writes the comment s. Newlines are kept; "--", which may not appear in comments,
+// is broken up.
+func (svg *SVG) Comment(s string) {
+	defer svg.lock()()
+	svg.started("comment")
+	svg.println("<!-- " + commentbody(s) + " -->")
+}
+
+// CommentIf writes the comment s, as Comment does, if on is set
+func (svg *SVG) CommentIf(on bool, s string) {
+	if on {
+		svg.Comment(s)
+	}
+}
+
+// DescAttr specifies the text of a description tag, with attributes, for example an id
+func (svg *SVG) DescAttr(s string, attrs ...string) {
+	defer svg.lock()()
+	svg.tt("desc", s, attrs...)
+}
+
+// TitleAttr specifies the text of a title tag, with attributes, for example an id
+func (svg *SVG) TitleAttr(s string, attrs ...string) {
+	defer svg.lock()()
+	svg.tt("title", s, attrs...)
+}
+
+// StartAccessible begins the SVG document with the width w and height h, as an image
+// labelled by the title and described by desc, which follow it; desc is omitted if empty.
+func (svg *SVG) StartAccessible(w, h int, title, desc string) {
+	unlock := svg.lock()
+	tid, did := svg.uid("title"), svg.uid("desc")
+	unlock()
+	labels := tid
+	if desc != "" {
+		labels += " " + did
+	}
+	svg.Start(w, h, `role="img"`, `aria-labelledby="`+labels+`"`)
+	svg.TitleAttr(title, `id="`+tid+`"`)
+	if desc != "" {
+		svg.DescAttr(desc, `id="`+did+`"`)
+	}
+}
 
 // Link begins a link named "name", with the specified title.
 // Standard Reference: http://www.w3.org/TR/SVG11/linking.html#Links
-func (svg *SVG) Link(href string, title string) {
-	svg.printf("<a xlink:href=\"%s\" xlink:title=\"", href)
-	xml.Escape(svg.Writer, []byte(title))
+func (svg *SVG) Link(link string, title string) {
+	defer svg.lock()()
+	svg.count("a")
+	svg.push("a")
+	if svg.unsafelink(link) {
+		link = ""
+	}
+	if svg.svg2 {
+		svg.printf("<a %s>\n", svg.href(link))
+		if title != "" {
+			svg.tt("title", title)
+		}
+		return
+	}
+	svg.printf(`<a %s xlink:title="`, svg.href(link))
+	svg.escape(title)
 	svg.println("\">")
 }
 
 // LinkEnd ends a link.
-func (svg *SVG) LinkEnd() { svg.println(`</a>`) }
+func (svg *SVG) LinkEnd() {
+	defer svg.lock()()
+	svg.pop("a")
+	svg.println(`</a>`)
+}
 
 // Use places the object referenced at link at the location x, y, with optional style.
 // Standard Reference: http://www.w3.org/TR/SVG11/struct.html#UseElement
 func (svg *SVG) Use(x int, y int, link string, s ...string) {
-	svg.printf(`<use %s %s %s`, loc(x, y), href(link), endstyle(s, emptyclose))
+	defer svg.lock()()
+	if svg.blockedref("use", link) || svg.unsafelink(link) {
+		return
+	}
+	svg.count("use")
+	svg.printf(`<use %s %s %s`, loc(x, y), svg.href(link), svg.endstyle(s, emptyclose))
+}
+
+// UseDim places the object referenced at link at the location x, y with dimension w, h,
+// as needed for symbols with their own viewbox, with optional style.
+func (svg *SVG) UseDim(x, y, w, h int, link string, s ...string) {
+	defer svg.lock()()
+	if svg.blockedref("use", link) || svg.unsafelink(link) {
+		return
+	}
+	svg.count("use")
+	svg.printf(`<use %s %s %s`, dim(x, y, w, h), svg.href(link), svg.endstyle(s, emptyclose))
+}
+
+// View defines a named view with the viewbox minx, miny, vw, vh, shown when the document is
+// referred to with the fragment #id, and the preserveAspectRatio value par, omitted if empty.
+// Standard Reference: http://www.w3.org/TR/SVG11/linking.html#ViewElement
+func (svg *SVG) View(id string, minx, miny, vw, vh int, par string) {
+	defer svg.lock()()
+	svg.count("view")
+	svg.printf("<view %s "+vbfmt, svg.idattr(id), minx, miny, vw, vh)
+	if par != "" {
+		svg.printf(` preserveAspectRatio="%s"`, attrescape(par))
+	}
+	svg.print(emptyclose)
 }
 
 // Mask creates a mask with a specified id, dimension, and optional style.
 func (svg *SVG) Mask(id string, x int, y int, w int, h int, s ...string) {
-	svg.printf(`<mask id="%s" x="%d" y="%d" width="%d" height="%d" %s`, id, x, y, w, h, endstyle(s, `>`))
+	defer svg.lock()()
+	svg.count("mask")
+	svg.push("mask")
+	svg.printf(`<mask %s x="%d" y="%d" width="%d" height="%d" %s`, svg.idattr(id), x, y, w, h, svg.endstyle(s, `>`))
+}
+
+// MaskUnits creates a mask like Mask, with fractional dimensions, and the units of its region and
+// content ("user" or "obj", or the full attribute values), such as 0-1 fractions of the
+// masked object for "obj". Empty units are omitted, as are invalid ones, with a warning.
+func (svg *SVG) MaskUnits(id string, x, y, w, h float64, maskUnits, contentUnits string, s ...string) {
+	defer svg.lock()()
+	svg.count("mask")
+	svg.push("mask")
+	svg.printf(`<mask %s x="%s" y="%s" width="%s" height="%s"%s%s %s`, svg.idattr(id),
+		svg.num(x), svg.num(y), svg.num(w), svg.num(h),
+		svg.unitsopt("maskUnits", maskUnits), svg.unitsopt("maskContentUnits", contentUnits), svg.endstyle(s, `>`))
 }
 
 // MaskEnd ends a Mask.
-func (svg *SVG) MaskEnd() { svg.println(`</mask>`) }
+func (svg *SVG) MaskEnd() {
+	defer svg.lock()()
+	svg.pop("mask")
+	svg.println(`</mask>`)
+}
 
 // Shapes
 
 // Circle centered at x,y, with radius r, with optional style.
 // Standard Reference: http://www.w3.org/TR/SVG11/shapes.html#CircleElement
 func (svg *SVG) Circle(x int, y int, r int, s ...string) {
-	svg.printf(`<circle cx="%d" cy="%d" r="%d" %s`, x, y, r, endstyle(s, emptyclose))
+	defer svg.lock()()
+	if svg.decorative(s) {
+		return
+	}
+	svg.count("circle")
+	svg.printf(`<circle cx="%d" cy="%d" r="%d" %s`, x, y, r, svg.endstyle(s, emptyclose))
 }
 
 // Ellipse centered at x,y, centered at x,y with radii w, and h, with optional style.
 // Standard Reference: http://www.w3.org/TR/SVG11/shapes.html#EllipseElement
 func (svg *SVG) Ellipse(x int, y int, w int, h int, s ...string) {
+	defer svg.lock()()
+	if svg.decorative(s) {
+		return
+	}
+	svg.count("ellipse")
 	svg.printf(`<ellipse cx="%d" cy="%d" rx="%d" ry="%d" %s`,
-		x, y, w, h, endstyle(s, emptyclose))
+		x, y, w, h, svg.endstyle(s, emptyclose))
 }
 
 // Polygon draws a series of line segments using an array of x, y coordinates, with optional style.
+// If x and y differ in length, the longer is truncated, and ErrMismatchedPoints is reported by Err.
 // Standard Reference: http://www.w3.org/TR/SVG11/shapes.html#PolygonElement
 func (svg *SVG) Polygon(x []int, y []int, s ...string) {
+	defer svg.lock()()
+	if svg.decorative(s) {
+		return
+	}
 	svg.poly(x, y, "polygon", s...)
 }
 
 // Rect draws a rectangle with upper left-hand corner at x,y, with width w, and height h, with optional style
 // Standard Reference: http://www.w3.org/TR/SVG11/shapes.html#RectElement
 func (svg *SVG) Rect(x int, y int, w int, h int, s ...string) {
-	// svg.printf(`<rect %s %s`, dim(x, y, w, h), endstyle(s, emptyclose))
-	svg.printf(`<rect x="%d" y="%d" width="%d" height="%d"`, x, y, w, h)
-
-	if len(s) > 0 {
-		for i := 0; i < len(s); i++ {
-			if strings.Index(s[i], "=") > 0 {
-				svg.print(" ", s[i])
-			} else {
-				svg.printf(` style="%s"`, s[i])
-			}
-		}
+	defer svg.lock()()
+	if svg.decorative(s) {
+		return
 	}
-	svg.print(emptyclose)
+	svg.count("rect")
+	svg.printf(`<rect %s %s`, dim(x, y, w, h), svg.endstyle(s, emptyclose))
 }
 
-// CenterRect draws a rectangle with its center at x,y, with width w, and height h, with optional style
+// CenterRect draws a rectangle with its center at x,y, with width w, and height h, with optional style.
+// Half units are rounded according to the rounding policy.
 func (svg *SVG) CenterRect(x int, y int, w int, h int, s ...string) {
-	svg.Rect(x-(w/2), y-(h/2), w, h, s...)
+	svg.Rect(x-half(w, svg.rounding), y-half(h, svg.rounding), w, h, s...)
+}
+
+// CenterSquare draws a square with its center at x,y, with sides of length size, with optional style.
+// Half units are rounded according to the rounding policy.
+func (svg *SVG) CenterSquare(x int, y int, size int, s ...string) {
+	svg.CenterRect(x, y, size, size, s...)
+}
+
+// CenterEllipse draws an ellipse with its center at x,y, with width w, and height h, with optional style.
+// The radii are rounded according to the rounding policy.
+func (svg *SVG) CenterEllipse(x int, y int, w int, h int, s ...string) {
+	svg.Ellipse(x, y, half(w, svg.rounding), half(h, svg.rounding), s...)
 }
 
 // Roundrect draws a rounded rectangle with upper the left-hand corner at x,y,
@@ -345,7 +1230,12 @@ func (svg *SVG) CenterRect(x int, y int, w int, h int, s ...string) {
 // Style is optional.
 // Standard Reference: http://www.w3.org/TR/SVG11/shapes.html#RectElement
 func (svg *SVG) Roundrect(x int, y int, w int, h int, rx int, ry int, s ...string) {
-	svg.printf(`<rect %s rx="%d" ry="%d" %s`, dim(x, y, w, h), rx, ry, endstyle(s, emptyclose))
+	defer svg.lock()()
+	if svg.decorative(s) {
+		return
+	}
+	svg.count("rect")
+	svg.printf(`<rect %s rx="%d" ry="%d" %s`, dim(x, y, w, h), rx, ry, svg.endstyle(s, emptyclose))
 }
 
 // Square draws a square with upper left corner at x,y with sides of length l, with optional style.
@@ -355,9 +1245,21 @@ func (svg *SVG) Square(x int, y int, l int, s ...string) {
 
 // Paths
 
-// Path draws an arbitrary path, the caller is responsible for structuring the path data
+// Path draws an arbitrary path, the caller is responsible for structuring the path data.
+// In strict mode, invalid path data is not drawn, and latches ErrInvalidPath.
 func (svg *SVG) Path(d string, s ...string) {
-	svg.printf(`<path d="%s" %s`, d, endstyle(s, emptyclose))
+	defer svg.lock()()
+	if svg.decorative(s) {
+		return
+	}
+	if svg.strict {
+		if err := pathdata(d); err != nil {
+			svg.latch(err)
+			return
+		}
+	}
+	svg.count("path")
+	svg.printf(`<path d="%s" %s`, d, svg.endstyle(s, emptyclose))
 }
 
 // Arc draws an elliptical arc, with optional style, beginning coordinate at sx,sy, ending coordinate at ex, ey
@@ -368,32 +1270,58 @@ func (svg *SVG) Path(d string, s ...string) {
 // otherwise the arc sweep is less than 180 degrees
 // http://www.w3.org/TR/SVG11/paths.html#PathDataEllipticalArcCommands
 func (svg *SVG) Arc(sx int, sy int, ax int, ay int, r int, large bool, sweep bool, ex int, ey int, s ...string) {
-	svg.printf(`%s A%s %d %s %s %s" %s`,
-		ptag(sx, sy), coord(ax, ay), r, onezero(large), onezero(sweep), coord(ex, ey), endstyle(s, emptyclose))
+	svg.ArcRot(sx, sy, ax, ay, float64(r), large, sweep, ex, ey, s...)
+}
+
+// ArcRot draws an elliptical arc like Arc, with a fractional x-axis rotation r, in degrees.
+// http://www.w3.org/TR/SVG11/paths.html#PathDataEllipticalArcCommands
+func (svg *SVG) ArcRot(sx int, sy int, ax int, ay int, r float64, large bool, sweep bool, ex int, ey int, s ...string) {
+	defer svg.lock()()
+	if svg.decorative(s) {
+		return
+	}
+	svg.count("path")
+	svg.printf(`%s A%s %g %s %s %s" %s`,
+		ptag(sx, sy), coord(ax, ay), r, onezero(large), onezero(sweep), coord(ex, ey), svg.endstyle(s, emptyclose))
 }
 
 // Bezier draws a cubic bezier curve, with optional style, beginning at sx,sy, ending at ex,ey
 // with control points at cx,cy and px,py.
 // Standard Reference: http://www.w3.org/TR/SVG11/paths.html#PathDataCubicBezierCommands
 func (svg *SVG) Bezier(sx int, sy int, cx int, cy int, px int, py int, ex int, ey int, s ...string) {
+	defer svg.lock()()
+	if svg.decorative(s) {
+		return
+	}
+	svg.count("path")
 	svg.printf(`%s C%s %s %s" %s`,
-		ptag(sx, sy), coord(cx, cy), coord(px, py), coord(ex, ey), endstyle(s, emptyclose))
+		ptag(sx, sy), coord(cx, cy), coord(px, py), coord(ex, ey), svg.endstyle(s, emptyclose))
 }
 
 // Qbez draws a quadratic bezier curver, with optional style
 // beginning at sx,sy, ending at ex, sy with control points at cx, cy
 // Standard Reference: http://www.w3.org/TR/SVG11/paths.html#PathDataQuadraticBezierCommands
 func (svg *SVG) Qbez(sx int, sy int, cx int, cy int, ex int, ey int, s ...string) {
+	defer svg.lock()()
+	if svg.decorative(s) {
+		return
+	}
+	svg.count("path")
 	svg.printf(`%s Q%s %s" %s`,
-		ptag(sx, sy), coord(cx, cy), coord(ex, ey), endstyle(s, emptyclose))
+		ptag(sx, sy), coord(cx, cy), coord(ex, ey), svg.endstyle(s, emptyclose))
 }
 
 // Qbezier draws a Quadratic Bezier curve, with optional style, beginning at sx, sy, ending at tx,ty
 // with control points are at cx,cy, ex,ey.
 // Standard Reference: http://www.w3.org/TR/SVG11/paths.html#PathDataQuadraticBezierCommands
 func (svg *SVG) Qbezier(sx int, sy int, cx int, cy int, ex int, ey int, tx int, ty int, s ...string) {
+	defer svg.lock()()
+	if svg.decorative(s) {
+		return
+	}
+	svg.count("path")
 	svg.printf(`%s Q%s %s T%s" %s`,
-		ptag(sx, sy), coord(cx, cy), coord(ex, ey), coord(tx, ty), endstyle(s, emptyclose))
+		ptag(sx, sy), coord(cx, cy), coord(ex, ey), coord(tx, ty), svg.endstyle(s, emptyclose))
 }
 
 // Lines
@@ -401,12 +1329,22 @@ func (svg *SVG) Qbezier(sx int, sy int, cx int, cy int, ex int, ey int, tx int,
 // Line draws a straight line between two points, with optional style.
 // Standard Reference: http://www.w3.org/TR/SVG11/shapes.html#LineElement
 func (svg *SVG) Line(x1 int, y1 int, x2 int, y2 int, s ...string) {
-	svg.printf(`<line x1="%d" y1="%d" x2="%d" y2="%d" %s`, x1, y1, x2, y2, endstyle(s, emptyclose))
+	defer svg.lock()()
+	if svg.decorative(s) {
+		return
+	}
+	svg.count("line")
+	svg.printf(`<line x1="%d" y1="%d" x2="%d" y2="%d" %s`, x1, y1, x2, y2, svg.endstyle(s, emptyclose))
 }
 
 // Polyline draws connected lines between coordinates, with optional style.
+// If x and y differ in length, the longer is truncated, and ErrMismatchedPoints is reported by Err.
 // Standard Reference: http://www.w3.org/TR/SVG11/shapes.html#PolylineElement
 func (svg *SVG) Polyline(x []int, y []int, s ...string) {
+	defer svg.lock()()
+	if svg.decorative(s) {
+		return
+	}
 	svg.poly(x, y, "polyline", s...)
 }
 
@@ -414,48 +1352,77 @@ func (svg *SVG) Polyline(x []int, y []int, s ...string) {
 // width w, and height h, referenced at link, with optional style.
 // Standard Reference: http://www.w3.org/TR/SVG11/struct.html#ImageElement
 func (svg *SVG) Image(x int, y int, w int, h int, link string, s ...string) {
-	svg.printf(`<image %s %s %s`, dim(x, y, w, h), href(link), endstyle(s, emptyclose))
+	defer svg.lock()()
+	if svg.decorative(s) || svg.blockedref("image", link) || svg.unsafelink(link) {
+		return
+	}
+	svg.count("image")
+	svg.printf(`<image %s %s %s`, dim(x, y, w, h), svg.href(link), svg.endstyle(s, emptyclose))
 }
 
 // Text places the specified text, t at x,y according to the style specified in s
 // Standard Reference: http://www.w3.org/TR/SVG11/text.html#TextElement
 func (svg *SVG) Text(x int, y int, t string, s ...string) {
-	svg.printf(`<text %s %s`, loc(x, y), endstyle(s, ">"))
-	xml.Escape(svg.Writer, []byte(t))
+	defer svg.lock()()
+	if svg.decorative(s) {
+		return
+	}
+	svg.count("text")
+	svg.printf(`<text %s %s`, loc(x, y), svg.endstyle(s, ">"))
+	svg.escape(t)
 	svg.println(`</text>`)
+	svg.textitem("text", t, x, y, isdecorative(s))
 }
 
 // Textspan begins text, assuming a tspan will be included, end with TextEnd()
 // Standard Reference: https://www.w3.org/TR/SVG11/text.html#TSpanElement
 func (svg *SVG) Textspan(x int, y int, t string, s ...string) {
-	svg.printf(`<text %s %s`, loc(x, y), endstyle(s, ">"))
-	xml.Escape(svg.Writer, []byte(t))
+	defer svg.lock()()
+	svg.count("text")
+	svg.push("text")
+	svg.printf(`<text %s %s`, loc(x, y), svg.endstyle(s, ">"))
+	svg.escape(t)
+	svg.span = TextItem{X: x, Y: y, Decorative: isdecorative(s)}
+	svg.textitem("text", t, x, y, svg.span.Decorative)
 }
 
 // Span makes styled spanned text, should be proceeded by Textspan
 // Standard Reference: https://www.w3.org/TR/SVG11/text.html#TSpanElement
 func (svg *SVG) Span(t string, s ...string) {
+	defer svg.lock()()
 	if len(s) == 0 {
-		xml.Escape(svg.Writer, []byte(t))
+		svg.escape(t)
+		svg.textitem("text", t, svg.span.X, svg.span.Y, svg.span.Decorative)
 		return
 	}
-	svg.printf(`<tspan %s`, endstyle(s, ">"))
-	xml.Escape(svg.Writer, []byte(t))
+	svg.count("tspan")
+	svg.printf(`<tspan %s`, svg.endstyle(s, ">"))
+	svg.escape(t)
 	svg.printf(`</tspan>`)
+	svg.textitem("tspan", t, svg.span.X, svg.span.Y, svg.span.Decorative || isdecorative(s))
 }
 
 // TextEnd ends spanned text
 // Standard Reference: https://www.w3.org/TR/SVG11/text.html#TSpanElement
 func (svg *SVG) TextEnd() {
+	defer svg.lock()()
+	svg.pop("text")
 	svg.println(`</text>`)
 }
 
 // Textpath places text optionally styled text along a previously defined path
 // Standard Reference: http://www.w3.org/TR/SVG11/text.html#TextPathElement
 func (svg *SVG) Textpath(t string, pathid string, s ...string) {
-	svg.printf("<text %s<textPath xlink:href=\"%s\">", endstyle(s, ">"), pathid)
-	xml.Escape(svg.Writer, []byte(t))
+	defer svg.lock()()
+	if svg.unsafelink(pathid) {
+		return
+	}
+	svg.count("text")
+	svg.count("textPath")
+	svg.printf("<text %s<textPath %s>", svg.endstyle(s, ">"), svg.href(pathid))
+	svg.escape(t)
 	svg.println(`</textPath></text>`)
+	svg.textitem("textPath", t, 0, 0, isdecorative(s))
 }
 
 // Textlines places a series of lines of text starting at x,y, at the specified size, fill, and alignment.
@@ -469,6 +1436,35 @@ func (svg *SVG) Textlines(x, y int, s []string, size, spacing int, fill, align s
 	svg.Gend()
 }
 
+// TextMultiline places the lines of t, separated by newlines, as text at x,y, with optional style.
+// Each line is a tspan at x, following the line before it by lineheight ems. Empty lines
+// hold a no-break space, so that they still advance to the next line.
+func (svg *SVG) TextMultiline(x, y int, t string, lineheight float64, s ...string) {
+	defer svg.lock()()
+	if svg.decorative(s) {
+		return
+	}
+	svg.count("text")
+	svg.printf(`<text %s %s`, loc(x, y), svg.endstyle(s, ">"))
+	if t != "" {
+		for i, line := range strings.Split(t, "\n") {
+			dy := "0"
+			if i > 0 {
+				dy = svg.num(lineheight) + "em"
+			}
+			svg.count("tspan")
+			svg.printf(`<tspan x="%d" dy="%s">`, x, dy)
+			if line = strings.TrimSuffix(line, "\r"); line == "" {
+				line = "\u00a0"
+			}
+			svg.escape(line)
+			svg.print(`</tspan>`)
+		}
+	}
+	svg.println(`</text>`)
+	svg.textitem("text", t, x, y, isdecorative(s))
+}
+
 // Colors
 
 // RGB specifies a fill color in terms of a (r)ed, (g)reen, (b)lue triple.
@@ -486,10 +1482,26 @@ func (svg *SVG) RGBA(r int, g int, b int, a float64) string {
 
 // LinearGradient constructs a linear color gradient identified by id,
 // along the vector defined by (x1,y1), and (x2,y2).
-// The stop color sequence defined in sc. Coordinates are expressed as percentages.
-func (svg *SVG) LinearGradient(id string, x1, y1, x2, y2 uint8, sc []Offcolor) {
-	svg.printf("<linearGradient id=\"%s\" x1=\"%d%%\" y1=\"%d%%\" x2=\"%d%%\" y2=\"%d%%\">\n",
-		id, pct(x1), pct(y1), pct(x2), pct(y2))
+// The stop color sequence defined in sc, with optional attributes, such as SpreadMethod and GradientTransform.
+// Coordinates are expressed as percentages.
+func (svg *SVG) LinearGradient(id string, x1, y1, x2, y2 uint8, sc []Offcolor, s ...string) {
+	defer svg.lock()()
+	svg.count("linearGradient")
+	svg.printf("<linearGradient %s x1=\"%d%%\" y1=\"%d%%\" x2=\"%d%%\" y2=\"%d%%\"%s",
+		svg.idattr(id), pct(x1), pct(y1), pct(x2), pct(y2), svg.endattrs(s, ">\n"))
+	svg.stopcolor(sc)
+	svg.println("</linearGradient>")
+}
+
+// LinearGradientUnits constructs a linear color gradient identified by id, along the vector
+// defined by (x1,y1), and (x2,y2), in the coordinates specified by units: "user" for
+// user space, spanning elements, or "obj" for fractions of each element's bounding box.
+// The stop color sequence defined in sc, with optional attributes.
+func (svg *SVG) LinearGradientUnits(id string, x1, y1, x2, y2 float64, units string, sc []Offcolor, s ...string) {
+	defer svg.lock()()
+	svg.count("linearGradient")
+	svg.printf("<linearGradient %s x1=\"%s\" y1=\"%s\" x2=\"%s\" y2=\"%s\" gradientUnits=\"%s\"%s",
+		svg.idattr(id), svg.num(x1), svg.num(y1), svg.num(x2), svg.num(y2), unitsattr(units), svg.endattrs(s, ">\n"))
 	svg.stopcolor(sc)
 	svg.println("</linearGradient>")
 }
@@ -497,24 +1509,119 @@ func (svg *SVG) LinearGradient(id string, x1, y1, x2, y2 uint8, sc []Offcolor) {
 // RadialGradient constructs a radial color gradient identified by id,
 // centered at (cx,cy), with a radius of r.
 // (fx, fy) define the location of the focal point of the light source.
-// The stop color sequence defined in sc.
+// The stop color sequence defined in sc, with optional attributes, such as SpreadMethod and GradientTransform.
 // Coordinates are expressed as percentages.
-func (svg *SVG) RadialGradient(id string, cx, cy, r, fx, fy uint8, sc []Offcolor) {
-	svg.printf("<radialGradient id=\"%s\" cx=\"%d%%\" cy=\"%d%%\" r=\"%d%%\" fx=\"%d%%\" fy=\"%d%%\">\n",
-		id, pct(cx), pct(cy), pct(r), pct(fx), pct(fy))
+func (svg *SVG) RadialGradient(id string, cx, cy, r, fx, fy uint8, sc []Offcolor, s ...string) {
+	defer svg.lock()()
+	svg.count("radialGradient")
+	svg.printf("<radialGradient %s cx=\"%d%%\" cy=\"%d%%\" r=\"%d%%\" fx=\"%d%%\" fy=\"%d%%\"%s",
+		svg.idattr(id), pct(cx), pct(cy), pct(r), pct(fx), pct(fy), svg.endattrs(s, ">\n"))
+	svg.stopcolor(sc)
+	svg.println("</radialGradient>")
+}
+
+// RadialGradientExt constructs a radial color gradient identified by id, centered at (cx,cy),
+// with a radius of r, and a focal circle centered at (fx,fy) with a radius of fr.
+// Negative fx, fy or fr are omitted, so that the renderer's defaults apply.
+// For units "user" or "userSpaceOnUse", coordinates are in user space; otherwise they are
+// percentages of each element's bounding box. gradientUnits is written only when units is provided.
+// The stop color sequence defined in sc, with optional attributes.
+func (svg *SVG) RadialGradientExt(id string, cx, cy, r, fx, fy, fr float64, units string, sc []Offcolor, s ...string) {
+	defer svg.lock()()
+	svg.count("radialGradient")
+	coord := func(name string, v float64) string {
+		if unitsattr(units) == "userSpaceOnUse" {
+			return fmt.Sprintf(` %s="%s"`, name, svg.num(v))
+		}
+		return fmt.Sprintf(` %s="%s"`, name, svg.pctf(v))
+	}
+	a := coord("cx", cx) + coord("cy", cy) + coord("r", r)
+	for _, f := range []struct {
+		name string
+		v    float64
+	}{{"fx", fx}, {"fy", fy}, {"fr", fr}} {
+		if f.v >= 0 {
+			a += coord(f.name, f.v)
+		}
+	}
+	if units != "" {
+		a += fmt.Sprintf(` gradientUnits="%s"`, unitsattr(units))
+	}
+	svg.printf("<radialGradient %s%s%s", svg.idattr(id), a, svg.endattrs(s, ">\n"))
 	svg.stopcolor(sc)
 	svg.println("</radialGradient>")
 }
 
+// LinearGradientPercent constructs a linear color gradient identified by id, along the vector
+// defined by (x1,y1), and (x2,y2), expressed as percentages, which may be fractional or over 100,
+// with the stop color sequence defined in sc, and optional attributes.
+func (svg *SVG) LinearGradientPercent(id string, x1, y1, x2, y2 float64, sc []Stop, s ...string) {
+	defer svg.lock()()
+	svg.count("linearGradient")
+	svg.printf("<linearGradient %s x1=\"%s\" y1=\"%s\" x2=\"%s\" y2=\"%s\"%s",
+		svg.idattr(id), svg.pctf(x1), svg.pctf(y1), svg.pctf(x2), svg.pctf(y2), svg.endattrs(s, ">\n"))
+	svg.stops(sc)
+	svg.println("</linearGradient>")
+}
+
+// RadialGradientPercent constructs a radial color gradient identified by id, centered at (cx,cy),
+// with a radius of r, and the focal point (fx,fy), expressed as percentages, which may be fractional
+// or over 100, with the stop color sequence defined in sc, and optional attributes.
+func (svg *SVG) RadialGradientPercent(id string, cx, cy, r, fx, fy float64, sc []Stop, s ...string) {
+	defer svg.lock()()
+	svg.count("radialGradient")
+	svg.printf("<radialGradient %s cx=\"%s\" cy=\"%s\" r=\"%s\" fx=\"%s\" fy=\"%s\"%s",
+		svg.idattr(id), svg.pctf(cx), svg.pctf(cy), svg.pctf(r), svg.pctf(fx), svg.pctf(fy), svg.endattrs(s, ">\n"))
+	svg.stops(sc)
+	svg.println("</radialGradient>")
+}
+
+// SpreadMethod returns the spreadMethod attribute of a gradient, for method "pad", "reflect" or "repeat";
+// other methods are replaced by "pad".
+func SpreadMethod(method string) string {
+	switch method {
+	case "pad", "reflect", "repeat":
+		break
+	default:
+		method = "pad"
+	}
+	return `spreadMethod="` + method + `"`
+}
+
+// GradientTransform returns the gradientTransform attribute of a gradient, for the transform t,
+// for example "rotate(45)".
+func GradientTransform(t string) string { return `gradientTransform="` + attrescape(t) + `"` }
+
 // stopcolor is a utility function used by the gradient functions
-// to define a sequence of offsets (expressed as percentages) and colors
+// to define a sequence of offsets (expressed as percentages) and colors.
+// Negative opacities are omitted. In strict mode, invalid colors latch ErrInvalidColor.
 func (svg *SVG) stopcolor(oc []Offcolor) {
 	for _, v := range oc {
-		svg.printf("<stop offset=\"%d%%\" stop-color=\"%s\" stop-opacity=\"%.2f\"/>\n",
-			pct(v.Offset), v.Color, v.Opacity)
+		svg.stop(fmt.Sprintf("%d%%", pct(v.Offset)), v.Color, v.Opacity)
 	}
 }
 
+// stops defines a sequence of stops with offsets expressed as fractional percentages
+func (svg *SVG) stops(sc []Stop) {
+	for _, v := range sc {
+		svg.stop(svg.pctf(v.Offset), v.Color, v.Opacity)
+	}
+}
+
+// stop defines a gradient stop
+func (svg *SVG) stop(offset, color string, opacity float64) {
+	if svg.strict && !ValidColor(color) {
+		svg.warn("invalid stop color %q", color)
+		svg.latch(ErrInvalidColor)
+	}
+	svg.count("stop")
+	if opacity < 0 {
+		svg.printf("<stop offset=\"%s\" stop-color=\"%s\"/>\n", offset, attrescape(color))
+		return
+	}
+	svg.printf("<stop offset=\"%s\" stop-color=\"%s\" stop-opacity=\"%.2f\"/>\n", offset, attrescape(color), opacity)
+}
+
 // Filter Effects:
 // Most functions have common attributes (in, in2, result) defined in type Filterspec
 // used as a common first argument.
@@ -522,18 +1629,25 @@ func (svg *SVG) stopcolor(oc []Offcolor) {
 // Filter begins a filter set
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#FilterElement
 func (svg *SVG) Filter(id string, s ...string) {
-	svg.printf(`<filter id="%s" %s`, id, endstyle(s, ">\n"))
+	defer svg.lock()()
+	svg.count("filter")
+	svg.push("filter")
+	svg.printf(`<filter %s %s`, svg.idattr(id), svg.endstyle(s, ">\n"))
 }
 
 // Fend ends a filter set
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#FilterElement
 func (svg *SVG) Fend() {
+	defer svg.lock()()
+	svg.pop("filter")
 	svg.println(`</filter>`)
 }
 
 // FeBlend specifies a Blend filter primitive
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feBlendElement
 func (svg *SVG) FeBlend(fs Filterspec, mode string, s ...string) {
+	defer svg.lock()()
+	svg.count("feBlend")
 	switch mode {
 	case "normal", "multiply", "screen", "darken", "lighten":
 		break
@@ -541,114 +1655,158 @@ func (svg *SVG) FeBlend(fs Filterspec, mode string, s ...string) {
 		mode = "normal"
 	}
 	svg.printf(`<feBlend %s mode="%s" %s`,
-		fsattr(fs), mode, endstyle(s, emptyclose))
+		fsattr(fs), mode, svg.endstyle(s, emptyclose))
 }
 
 // FeColorMatrix specifies a color matrix filter primitive, with matrix values
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feColorMatrixElement
 func (svg *SVG) FeColorMatrix(fs Filterspec, values [20]float64, s ...string) {
+	defer svg.lock()()
+	svg.count("feColorMatrix")
 	svg.printf(`<feColorMatrix %s type="matrix" values="`, fsattr(fs))
 	for _, v := range values {
 		svg.printf(`%g `, v)
 	}
-	svg.printf(`" %s`, endstyle(s, emptyclose))
+	svg.printf(`"%s`, svg.endattrs(s, emptyclose))
 }
 
 // FeColorMatrixHue specifies a color matrix filter primitive, with hue rotation values
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feColorMatrixElement
 func (svg *SVG) FeColorMatrixHue(fs Filterspec, value float64, s ...string) {
+	defer svg.lock()()
+	svg.count("feColorMatrix")
 	if value < -360 || value > 360 {
 		value = 0
 	}
 	svg.printf(`<feColorMatrix %s type="hueRotate" values="%g" %s`,
-		fsattr(fs), value, endstyle(s, emptyclose))
+		fsattr(fs), value, svg.endstyle(s, emptyclose))
 }
 
 // FeColorMatrixSaturate specifies a color matrix filter primitive, with saturation values
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feColorMatrixElement
 func (svg *SVG) FeColorMatrixSaturate(fs Filterspec, value float64, s ...string) {
+	defer svg.lock()()
+	svg.count("feColorMatrix")
 	if value < 0 || value > 1 {
 		value = 1
 	}
 	svg.printf(`<feColorMatrix %s type="saturate" values="%g" %s`,
-		fsattr(fs), value, endstyle(s, emptyclose))
+		fsattr(fs), value, svg.endstyle(s, emptyclose))
 }
 
-// FeColorMatrixLuminence specifies a color matrix filter primitive, with luminence values
+// FeColorMatrixLuminanceToAlpha specifies a color matrix filter primitive, converting luminance to alpha
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feColorMatrixElement
+func (svg *SVG) FeColorMatrixLuminanceToAlpha(fs Filterspec, s ...string) {
+	defer svg.lock()()
+	svg.count("feColorMatrix")
+	svg.printf(`<feColorMatrix %s type="luminanceToAlpha" %s`,
+		fsattr(fs), svg.endstyle(s, emptyclose))
+}
+
+// FeColorMatrixLuminence specifies a color matrix filter primitive, converting luminance to alpha
+//
+// Deprecated: use FeColorMatrixLuminanceToAlpha.
 func (svg *SVG) FeColorMatrixLuminence(fs Filterspec, s ...string) {
-	svg.printf(`<feColorMatrix %s type="luminenceToAlpha" %s`,
-		fsattr(fs), endstyle(s, emptyclose))
+	svg.FeColorMatrixLuminanceToAlpha(fs, s...)
 }
 
 // FeComponentTransfer begins a feComponent filter element
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feComponentTransferElement
 func (svg *SVG) FeComponentTransfer() {
+	defer svg.lock()()
+	svg.count("feComponentTransfer")
 	svg.println(`<feComponentTransfer>`)
 }
 
 // FeCompEnd ends a feComponent filter element
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feComponentTransferElement
 func (svg *SVG) FeCompEnd() {
+	defer svg.lock()()
 	svg.println(`</feComponentTransfer>`)
 }
 
-// FeComposite specifies a feComposite filter primitive
+// FeComposite specifies a feComposite filter primitive.
+// The k1-k4 values are only written for the arithmetic operator.
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feCompositeElement
 func (svg *SVG) FeComposite(fs Filterspec, operator string, k1, k2, k3, k4 int, s ...string) {
 	switch operator {
-	case "over", "in", "out", "atop", "xor", "arithmetic":
+	case "over", "in", "out", "atop", "xor":
 		break
+	case "arithmetic":
+		svg.FeCompositeArith(fs, float64(k1), float64(k2), float64(k3), float64(k4), s...)
+		return
 	default:
 		operator = "over"
 	}
-	svg.printf(`<feComposite %s operator="%s" k1="%d" k2="%d" k3="%d" k4="%d" %s`,
-		fsattr(fs), operator, k1, k2, k3, k4, endstyle(s, emptyclose))
+	defer svg.lock()()
+	svg.count("feComposite")
+	svg.printf(`<feComposite %s operator="%s" %s`, fsattr(fs), operator, svg.endstyle(s, emptyclose))
+}
+
+// FeCompositeArith specifies a feComposite filter primitive with the arithmetic operator,
+// combining the inputs as k1*in*in2 + k2*in + k3*in2 + k4
+// Standard reference: http://www.w3.org/TR/SVG11/filters.html#feCompositeElement
+func (svg *SVG) FeCompositeArith(fs Filterspec, k1, k2, k3, k4 float64, s ...string) {
+	defer svg.lock()()
+	svg.count("feComposite")
+	svg.printf(`<feComposite %s operator="arithmetic" k1="%g" k2="%g" k3="%g" k4="%g" %s`,
+		fsattr(fs), k1, k2, k3, k4, svg.endstyle(s, emptyclose))
 }
 
 // FeConvolveMatrix specifies a feConvolveMatrix filter primitive
-// Standard referencd: http://www.w3.org/TR/SVG11/filters.html#feConvolveMatrixElement
+// Standard reference: http://www.w3.org/TR/SVG11/filters.html#feConvolveMatrixElement
 func (svg *SVG) FeConvolveMatrix(fs Filterspec, matrix [9]int, s ...string) {
+	defer svg.lock()()
+	svg.count("feConvolveMatrix")
 	svg.printf(`<feConvolveMatrix %s kernelMatrix="%d %d %d %d %d %d %d %d %d" %s`,
 		fsattr(fs),
 		matrix[0], matrix[1], matrix[2],
 		matrix[3], matrix[4], matrix[5],
-		matrix[6], matrix[7], matrix[8], endstyle(s, emptyclose))
+		matrix[6], matrix[7], matrix[8], svg.endstyle(s, emptyclose))
 }
 
 // FeDiffuseLighting specifies a diffuse lighting filter primitive,
-// a container for light source elements, end with DiffuseEnd()
-// Standard reference: http://www.w3.org/TR/SVG11/filters.html#feComponentTransferElement
+// a container for light source elements, end with FeDiffEnd()
+// Standard reference: http://www.w3.org/TR/SVG11/filters.html#feDiffuseLightingElement
 func (svg *SVG) FeDiffuseLighting(fs Filterspec, scale, constant float64, s ...string) {
+	defer svg.lock()()
+	svg.count("feDiffuseLighting")
 	svg.printf(`<feDiffuseLighting %s surfaceScale="%g" diffuseConstant="%g" %s`,
-		fsattr(fs), scale, constant, endstyle(s, `>`))
+		fsattr(fs), scale, constant, svg.endstyle(s, `>`))
 }
 
 // FeDiffEnd ends a diffuse lighting filter primitive container
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feDiffuseLightingElement
 func (svg *SVG) FeDiffEnd() {
+	defer svg.lock()()
 	svg.println(`</feDiffuseLighting>`)
 }
 
 // FeDisplacementMap specifies a feDisplacementMap filter primitive
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feDisplacementMapElement
 func (svg *SVG) FeDisplacementMap(fs Filterspec, scale float64, xchannel, ychannel string, s ...string) {
+	defer svg.lock()()
+	svg.count("feDisplacementMap")
 	svg.printf(`<feDisplacementMap %s scale="%g" xChannelSelector="%s" yChannelSelector="%s" %s`,
-		fsattr(fs), scale, imgchannel(xchannel), ychannel, endstyle(s, emptyclose))
+		fsattr(fs), scale, imgchannel(xchannel), imgchannel(ychannel), svg.endstyle(s, emptyclose))
 }
 
 // FeDistantLight specifies a feDistantLight filter primitive
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feDistantLightElement
 func (svg *SVG) FeDistantLight(fs Filterspec, azimuth, elevation float64, s ...string) {
+	defer svg.lock()()
+	svg.count("feDistantLight")
 	svg.printf(`<feDistantLight %s azimuth="%g" elevation="%g" %s`,
-		fsattr(fs), azimuth, elevation, endstyle(s, emptyclose))
+		fsattr(fs), azimuth, elevation, svg.endstyle(s, emptyclose))
 }
 
 // FeFlood specifies a flood filter primitive
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feFloodElement
 func (svg *SVG) FeFlood(fs Filterspec, color string, opacity float64, s ...string) {
+	defer svg.lock()()
+	svg.count("feFlood")
 	svg.printf(`<feFlood %s flood-color="%s" flood-opacity="%g" %s`,
-		fsattr(fs), color, opacity, endstyle(s, emptyclose))
+		fsattr(fs), color, opacity, svg.endstyle(s, emptyclose))
 }
 
 // FeFunc{linear|Gamma|Table|Discrete} specify various types of feFunc{R|G|B|A} filter primitives
@@ -657,6 +1815,8 @@ func (svg *SVG) FeFlood(fs Filterspec, color string, opacity float64, s ...strin
 // FeFuncLinear specifies a linear style function for the feFunc{R|G|B|A} filter element
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feComponentTransferElement
 func (svg *SVG) FeFuncLinear(channel string, slope, intercept float64) {
+	defer svg.lock()()
+	svg.count("feFunc" + imgchannel(channel))
 	svg.printf(`<feFunc%s type="linear" slope="%g" intercept="%g"%s`,
 		imgchannel(channel), slope, intercept, emptyclose)
 }
@@ -664,6 +1824,8 @@ func (svg *SVG) FeFuncLinear(channel string, slope, intercept float64) {
 // FeFuncGamma specifies the curve values for gamma correction for the feFunc{R|G|B|A} filter element
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feComponentTransferElement
 func (svg *SVG) FeFuncGamma(channel string, amplitude, exponent, offset float64) {
+	defer svg.lock()()
+	svg.count("feFunc" + imgchannel(channel))
 	svg.printf(`<feFunc%s type="gamma" amplitude="%g" exponent="%g" offset="%g"%s`,
 		imgchannel(channel), amplitude, exponent, offset, emptyclose)
 }
@@ -671,6 +1833,8 @@ func (svg *SVG) FeFuncGamma(channel string, amplitude, exponent, offset float64)
 // FeFuncTable specifies the table of values for the feFunc{R|G|B|A} filter element
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feComponentTransferElement
 func (svg *SVG) FeFuncTable(channel string, tv []float64) {
+	defer svg.lock()()
+	svg.count("feFunc" + imgchannel(channel))
 	svg.printf(`<feFunc%s type="table"`, imgchannel(channel))
 	svg.tablevalues(`tableValues`, tv)
 }
@@ -678,6 +1842,8 @@ func (svg *SVG) FeFuncTable(channel string, tv []float64) {
 // FeFuncDiscrete specifies the discrete values for the feFunc{R|G|B|A} filter element
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feComponentTransferElement
 func (svg *SVG) FeFuncDiscrete(channel string, tv []float64) {
+	defer svg.lock()()
+	svg.count("feFunc" + imgchannel(channel))
 	svg.printf(`<feFunc%s type="discrete"`, imgchannel(channel))
 	svg.tablevalues(`tableValues`, tv)
 }
@@ -685,6 +1851,8 @@ func (svg *SVG) FeFuncDiscrete(channel string, tv []float64) {
 // FeGaussianBlur specifies a Gaussian Blur filter primitive
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feGaussianBlurElement
 func (svg *SVG) FeGaussianBlur(fs Filterspec, stdx, stdy float64, s ...string) {
+	defer svg.lock()()
+	svg.count("feGaussianBlur")
 	if stdx < 0 {
 		stdx = 0
 	}
@@ -692,29 +1860,39 @@ func (svg *SVG) FeGaussianBlur(fs Filterspec, stdx, stdy float64, s ...string) {
 		stdy = 0
 	}
 	svg.printf(`<feGaussianBlur %s stdDeviation="%g %g" %s`,
-		fsattr(fs), stdx, stdy, endstyle(s, emptyclose))
+		fsattr(fs), stdx, stdy, svg.endstyle(s, emptyclose))
 }
 
 // FeImage specifies a feImage filter primitive
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feImageElement
-func (svg *SVG) FeImage(href string, result string, s ...string) {
-	svg.printf(`<feImage xlink:href="%s" result="%s" %s`,
-		href, result, endstyle(s, emptyclose))
+func (svg *SVG) FeImage(link string, result string, s ...string) {
+	defer svg.lock()()
+	if svg.blockedref("feImage", link) || svg.unsafelink(link) {
+		return
+	}
+	svg.count("feImage")
+	svg.printf(`<feImage %s result="%s" %s`,
+		svg.href(link), result, svg.endstyle(s, emptyclose))
 }
 
 // FeMerge specifies a feMerge filter primitive, containing feMerge elements
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feMergeElement
 func (svg *SVG) FeMerge(nodes []string, s ...string) {
+	defer svg.lock()()
+	svg.count("feMerge")
 	svg.println(`<feMerge>`)
 	for _, n := range nodes {
+		svg.count("feMergeNode")
 		svg.printf("<feMergeNode in=\"%s\"/>\n", n)
 	}
 	svg.println(`</feMerge>`)
 }
 
-// FeMorphology specifies a feMorphologyLight filter primitive
+// FeMorphology specifies a feMorphology filter primitive
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feMorphologyElement
 func (svg *SVG) FeMorphology(fs Filterspec, operator string, xradius, yradius float64, s ...string) {
+	defer svg.lock()()
+	svg.count("feMorphology")
 	switch operator {
 	case "erode", "dilate":
 		break
@@ -722,65 +1900,87 @@ func (svg *SVG) FeMorphology(fs Filterspec, operator string, xradius, yradius fl
 		operator = "erode"
 	}
 	svg.printf(`<feMorphology %s operator="%s" radius="%g %g" %s`,
-		fsattr(fs), operator, xradius, yradius, endstyle(s, emptyclose))
+		fsattr(fs), operator, xradius, yradius, svg.endstyle(s, emptyclose))
 }
 
 // FeOffset specifies the feOffset filter primitive
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feOffsetElement
 func (svg *SVG) FeOffset(fs Filterspec, dx, dy int, s ...string) {
+	defer svg.lock()()
+	svg.count("feOffset")
 	svg.printf(`<feOffset %s dx="%d" dy="%d" %s`,
-		fsattr(fs), dx, dy, endstyle(s, emptyclose))
+		fsattr(fs), dx, dy, svg.endstyle(s, emptyclose))
 }
 
-// FePointLight specifies a fePpointLight filter primitive
+// FePointLight specifies a fePointLight filter primitive
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#fePointLightElement
 func (svg *SVG) FePointLight(x, y, z float64, s ...string) {
+	defer svg.lock()()
+	svg.count("fePointLight")
 	svg.printf(`<fePointLight x="%g" y="%g" z="%g" %s`,
-		x, y, z, endstyle(s, emptyclose))
+		x, y, z, svg.endstyle(s, emptyclose))
 }
 
 // FeSpecularLighting specifies a specular lighting filter primitive,
-// a container for light source elements, end with SpecularEnd()
+// a container for light source elements, end with FeSpecEnd()
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feSpecularLightingElement
 func (svg *SVG) FeSpecularLighting(fs Filterspec, scale, constant float64, exponent int, color string, s ...string) {
+	defer svg.lock()()
+	svg.count("feSpecularLighting")
 	svg.printf(`<feSpecularLighting %s surfaceScale="%g" specularConstant="%g" specularExponent="%d" lighting-color="%s" %s`,
-		fsattr(fs), scale, constant, exponent, color, endstyle(s, ">\n"))
+		fsattr(fs), scale, constant, exponent, color, svg.endstyle(s, ">\n"))
 }
 
 // FeSpecEnd ends a specular lighting filter primitive container
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feSpecularLightingElement
 func (svg *SVG) FeSpecEnd() {
+	defer svg.lock()()
 	svg.println(`</feSpecularLighting>`)
 }
 
 // FeSpotLight specifies a feSpotLight filter primitive
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feSpotLightElement
 func (svg *SVG) FeSpotLight(fs Filterspec, x, y, z, px, py, pz float64, s ...string) {
+	defer svg.lock()()
+	svg.count("feSpotLight")
 	svg.printf(`<feSpotLight %s x="%g" y="%g" z="%g" pointsAtX="%g" pointsAtY="%g" pointsAtZ="%g" %s`,
-		fsattr(fs), x, y, z, px, py, pz, endstyle(s, emptyclose))
+		fsattr(fs), x, y, z, px, py, pz, svg.endstyle(s, emptyclose))
 }
 
-// FeTile specifies the tile utility filter primitive
+// FeTile specifies the tile utility filter primitive.
+// A non-empty in specifies the input, taking precedence over fs.In.
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feTileElement
 func (svg *SVG) FeTile(fs Filterspec, in string, s ...string) {
-	svg.printf(`<feTile %s %s`, fsattr(fs), endstyle(s, emptyclose))
+	defer svg.lock()()
+	svg.count("feTile")
+	if in != "" {
+		fs.In = in
+	}
+	svg.printf(`<feTile %s %s`, fsattr(fs), svg.endstyle(s, emptyclose))
 }
 
 // FeTurbulence specifies a turbulence filter primitive
 // Standard reference: http://www.w3.org/TR/SVG11/filters.html#feTurbulenceElement
 func (svg *SVG) FeTurbulence(fs Filterspec, ftype string, bfx, bfy float64, octaves int, seed int64, stitch bool, s ...string) {
-	if bfx < 0 || bfx > 1 {
+	svg.FeTurbulencef(fs, ftype, bfx, bfy, octaves, float64(seed), stitch, s...)
+}
+
+// FeTurbulencef specifies a turbulence filter primitive, with a fractional seed.
+// An ftype beginning with "f" selects fractal noise, anything else turbulence;
+// negative base frequencies are replaced by zero.
+// Standard reference: http://www.w3.org/TR/SVG11/filters.html#feTurbulenceElement
+func (svg *SVG) FeTurbulencef(fs Filterspec, ftype string, bfx, bfy float64, octaves int, seed float64, stitch bool, s ...string) {
+	defer svg.lock()()
+	svg.count("feTurbulence")
+	if bfx < 0 {
 		bfx = 0
 	}
-	if bfy < 0 || bfy > 1 {
+	if bfy < 0 {
 		bfy = 0
 	}
-	switch ftype[0:1] {
-	case "f", "F":
+	if strings.HasPrefix(ftype, "f") || strings.HasPrefix(ftype, "F") {
 		ftype = "fractalNoise"
-	case "t", "T":
-		ftype = "turbulence"
-	default:
+	} else {
 		ftype = "turbulence"
 	}
 
@@ -790,8 +1990,8 @@ func (svg *SVG) FeTurbulence(fs Filterspec, ftype string, bfx, bfy float64, octa
 	} else {
 		ss = "noStitch"
 	}
-	svg.printf(`<feTurbulence %s type="%s" baseFrequency="%.2f %.2f" numOctaves="%d" seed="%d" stitchTiles="%s" %s`,
-		fsattr(fs), ftype, bfx, bfy, octaves, seed, ss, endstyle(s, emptyclose))
+	svg.printf(`<feTurbulence %s type="%s" baseFrequency="%s %s" numOctaves="%d" seed="%s" stitchTiles="%s" %s`,
+		fsattr(fs), ftype, svg.num(bfx), svg.num(bfy), octaves, svg.num(seed), ss, svg.endstyle(s, emptyclose))
 }
 
 // Filter Effects convenience functions, modeled after CSS versions
@@ -853,25 +2053,98 @@ func (svg *SVG) Sepia() {
 	svg.FeColorMatrix(Filterspec{}, sepiamatrix)
 }
 
+// Duotone defines, within a definition block, opened if one is not already, a filter identified
+// by id that maps the grayscale values of its source onto the range between the shadow and
+// highlight colors, returning the url reference to the filter.
+// Colors are specified in hex or rgb() form; unrecognized colors are treated as black (shadow) and white (highlight).
+func (svg *SVG) Duotone(id string, shadow, highlight string) string {
+	r1, g1, b1, ok := parsecolor(shadow)
+	if !ok {
+		r1, g1, b1 = 0, 0, 0
+	}
+	r2, g2, b2, ok := parsecolor(highlight)
+	if !ok {
+		r2, g2, b2 = 255, 255, 255
+	}
+	svg.indefs(func() {
+		svg.Filter(id, `color-interpolation-filters="sRGB"`)
+		svg.FeColorMatrixSaturate(Filterspec{}, 0)
+		svg.FeComponentTransfer()
+		svg.FeFuncTable("R", []float64{unitcolor(r1), unitcolor(r2)})
+		svg.FeFuncTable("G", []float64{unitcolor(g1), unitcolor(g2)})
+		svg.FeFuncTable("B", []float64{unitcolor(b1), unitcolor(b2)})
+		svg.FeCompEnd()
+		svg.Fend()
+	})
+	return "url(#" + id + ")"
+}
+
+// DuotoneImage places the image referenced at link at x,y with width w and height h,
+// treated with a duotone filter ranging from the shadow to the highlight color, with optional style.
+func (svg *SVG) DuotoneImage(x int, y int, w int, h int, link string, shadow, highlight string, s ...string) {
+	unlock := svg.lock()
+	id := svg.uid("duotone")
+	unlock()
+	ref := svg.Duotone(id, shadow, highlight)
+	svg.Image(x, y, w, h, link, append([]string{`filter="` + ref + `"`}, s...)...)
+}
+
 // Animation
 
+// Repeat specifies how an animation repeats: Count times, which may be fractional,
+// or, if Count is not positive, for Dur seconds. If neither is positive, the animation repeats indefinitely.
+type Repeat struct {
+	Count, Dur float64
+}
+
 // Animate animates the specified link, using the specified attribute
 // The animation starts at coordinate from, terminates at to, and repeats as specified
 func (svg *SVG) Animate(link, attr string, from, to int, duration float64, repeat int, s ...string) {
-	svg.printf(`<animate %s attributeName="%s" from="%d" to="%d" dur="%gs" repeatCount="%s" %s`,
-		href(link), attr, from, to, duration, repeatString(repeat), endstyle(s, emptyclose))
+	svg.AnimateRepeat(link, attr, from, to, duration, Repeat{Count: float64(repeat)}, s...)
+}
+
+// AnimateRepeat animates like Animate, repeating a fractional number of times, or for a duration
+func (svg *SVG) AnimateRepeat(link, attr string, from, to int, duration float64, repeat Repeat, s ...string) {
+	defer svg.lock()()
+	if svg.blocked("animate") || svg.unsafelink(link) {
+		return
+	}
+	svg.count("animate")
+	svg.printf(`<animate %s attributeName="%s" from="%d" to="%d" dur="%gs" %s %s`,
+		svg.href(link), attr, from, to, duration, repeatattr(repeat), svg.endstyle(s, emptyclose))
 }
 
 // AnimateMotion animates the referenced object along the specified path
 func (svg *SVG) AnimateMotion(link, path string, duration float64, repeat int, s ...string) {
-	svg.printf(`<animateMotion %s dur="%gs" repeatCount="%s" %s<mpath %s/></animateMotion>
-`, href(link), duration, repeatString(repeat), endstyle(s, ">"), href(path))
+	svg.AnimateMotionRepeat(link, path, duration, Repeat{Count: float64(repeat)}, s...)
+}
+
+// AnimateMotionRepeat animates like AnimateMotion, repeating a fractional number of times, or for a duration
+func (svg *SVG) AnimateMotionRepeat(link, path string, duration float64, repeat Repeat, s ...string) {
+	defer svg.lock()()
+	if svg.blocked("animateMotion") || svg.unsafelink(link) || svg.unsafelink(path) {
+		return
+	}
+	svg.count("animateMotion")
+	svg.count("mpath")
+	svg.printf(`<animateMotion %s dur="%gs" %s %s<mpath %s/></animateMotion>
+`, svg.href(link), duration, repeatattr(repeat), svg.endstyle(s, ">"), svg.href(path))
 }
 
 // AnimateTransform animates in the context of SVG transformations
 func (svg *SVG) AnimateTransform(link, ttype, from, to string, duration float64, repeat int, s ...string) {
-	svg.printf(`<animateTransform %s attributeName="transform" type="%s" from="%s" to="%s" dur="%gs" repeatCount="%s" %s`,
-		href(link), ttype, from, to, duration, repeatString(repeat), endstyle(s, emptyclose))
+	svg.AnimateTransformRepeat(link, ttype, from, to, duration, Repeat{Count: float64(repeat)}, s...)
+}
+
+// AnimateTransformRepeat animates like AnimateTransform, repeating a fractional number of times, or for a duration
+func (svg *SVG) AnimateTransformRepeat(link, ttype, from, to string, duration float64, repeat Repeat, s ...string) {
+	defer svg.lock()()
+	if svg.blocked("animateTransform") || svg.unsafelink(link) {
+		return
+	}
+	svg.count("animateTransform")
+	svg.printf(`<animateTransform %s attributeName="transform" type="%s" from="%s" to="%s" dur="%gs" %s %s`,
+		svg.href(link), ttype, from, to, duration, repeatattr(repeat), svg.endstyle(s, emptyclose))
 }
 
 // AnimateTranslate animates the translation transformation
@@ -901,6 +2174,22 @@ func (svg *SVG) AnimateSkewY(link string, from, to, duration float64, repeat int
 
 // Utility
 
+// AtomicGroup draws fn inside a group with optional style, and writes the whole group at once,
+// so that on a canvas made with NewSafe it is not interleaved with elements drawn by other goroutines.
+// fn must only draw on the canvas it is passed.
+func (svg *SVG) AtomicGroup(fn func(*SVG), s ...string) {
+	var buf bytes.Buffer
+	unlock := svg.lock()
+	g := svg.clone(&buf)
+	unlock()
+	g.Group(s...)
+	fn(g)
+	g.Gend()
+	defer svg.lock()()
+	svg.print(buf.String())
+	svg.merge(g)
+}
+
 // Grid draws a grid at the specified coordinate, dimensions, and spacing, with optional style.
 func (svg *SVG) Grid(x int, y int, w int, h int, n int, s ...string) {
 
@@ -920,6 +2209,37 @@ func (svg *SVG) Grid(x int, y int, w int, h int, n int, s ...string) {
 
 }
 
+// RenderChunks draws each of the chunks in turn, calling progress (if not nil) with the number
+// of chunks completed after each one. Rendering stops at the first chunk that causes an error
+// or panics, returning an error identifying the chunk. On a canvas made with NewBuffered,
+// the output of each completed chunk is flushed.
+func (svg *SVG) RenderChunks(chunks []func(*SVG), progress func(done, total int)) error {
+	for i, chunk := range chunks {
+		if err := svg.chunk(i, chunk); err != nil {
+			return err
+		}
+		svg.Flush()
+		if err := svg.Err(); err != nil {
+			return fmt.Errorf("svg: chunk %d: %w", i, err)
+		}
+		if progress != nil {
+			progress(i+1, len(chunks))
+		}
+	}
+	return nil
+}
+
+// chunk draws a single chunk, converting a panic to an error
+func (svg *SVG) chunk(i int, draw func(*SVG)) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("svg: chunk %d: panic: %v", i, r)
+		}
+	}()
+	draw(svg)
+	return nil
+}
+
 // Support functions
 
 // coordpair returns a coordinate pair as a string
@@ -932,66 +2252,367 @@ func sce(start, center, end int) string {
 	return fmt.Sprintf("%d %d %d", start, center, end)
 }
 
-// repeatString computes the repeat string for animation methods
-// repeat <= 0 --> "indefinite", otherwise the integer string
-func repeatString(n int) string {
-	if n > 0 {
-		return fmt.Sprintf("%d", n)
+// repeatattr computes the repeatCount or repeatDur attribute for animation methods.
+// A repeat of neither a positive count nor duration is indefinite.
+func repeatattr(r Repeat) string {
+	switch {
+	case r.Count > 0:
+		return fmt.Sprintf(`repeatCount="%g"`, r.Count)
+	case r.Dur > 0:
+		return fmt.Sprintf(`repeatDur="%gs"`, r.Dur)
 	}
-	return "indefinite"
+	return `repeatCount="indefinite"`
 }
 
 // style returns a style name,attribute string
 func style(s string) string {
 	if len(s) > 0 {
-		return fmt.Sprintf(`style="%s"`, s)
+		return `style="` + attrescape(s) + `"`
 	}
 	return s
 }
 
-// pp returns a series of polygon points
+// rawmark prefixes attribute strings marked by Raw
+const rawmark = "\x00raw\x00"
+
+// Raw marks a string of attributes to be written as is when passed as an optional
+// style or attribute argument. Unlike other arguments, its values are not escaped:
+// the caller is responsible for well-formed output.
+func Raw(s string) string { return rawmark + s }
+
+// Class returns the class attribute for the class names, escaped, to be passed as an optional
+// style or attribute argument. Empty names, and names containing spaces or quotes, are omitted;
+// with no names left, Class returns the empty string.
+func Class(names ...string) string {
+	valid := make([]string, 0, len(names))
+	for _, n := range names {
+		if n != "" && !strings.ContainsAny(n, " \t\r\n\f\"'") {
+			valid = append(valid, n)
+		}
+	}
+	if len(valid) == 0 {
+		return ""
+	}
+	return Raw(`class="` + attrescape(strings.Join(valid, " ")) + `"`)
+}
+
+// parseattrs splits a string of name="value" pairs. Values may be double quoted,
+// single quoted or unquoted; the string does not parse if a name is not a valid XML name.
+func parseattrs(s string) ([][2]string, bool) {
+	var a [][2]string
+	for s = strings.TrimSpace(s); len(s) > 0; s = strings.TrimSpace(s) {
+		n := strings.Index(s, "=")
+		if n < 0 {
+			return nil, false
+		}
+		name := strings.TrimSpace(s[:n])
+		if !xmlname(name) {
+			return nil, false
+		}
+		s = strings.TrimLeft(s[n+1:], " \t\r\n")
+		var value string
+		if len(s) > 0 && (s[0] == '"' || s[0] == '\'') {
+			end := strings.IndexByte(s[1:], s[0])
+			if end < 0 {
+				return nil, false
+			}
+			value, s = s[1:end+1], s[end+2:]
+			if len(s) > 0 && !strings.ContainsAny(s[:1], " \t\r\n") {
+				return nil, false
+			}
+		} else {
+			end := strings.IndexAny(s, " \t\r\n")
+			if end < 0 {
+				end = len(s)
+			}
+			value, s = s[:end], s[end:]
+		}
+		a = append(a, [2]string{name, value})
+	}
+	return a, len(a) > 0
+}
+
+// mapattrs returns the attributes in m, in order of name, omitting invalid names with a warning
+func (svg *SVG) mapattrs(m map[string]string) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		if !xmlname(name) {
+			svg.warn("invalid attribute name %q", name)
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	a := make([]string, len(names))
+	for i, name := range names {
+		a[i] = name + `="` + xmlescape(m[name]) + `"`
+	}
+	return a
+}
+
+// formatattrs makes a string of name="value" pairs, escaping the values
+func formatattrs(a [][2]string) string {
+	p := make([]string, len(a))
+	for i, v := range a {
+		p[i] = v[0] + `="` + attrescape(v[1]) + `"`
+	}
+	return strings.Join(p, " ")
+}
+
+// xmlname determines if s is a valid XML name, with at most one colon, separating a prefix,
+// as namespaces require
+func xmlname(s string) bool {
+	if prefix, local, ok := strings.Cut(s, ":"); ok {
+		return ncname(prefix) && ncname(local)
+	}
+	return ncname(s)
+}
+
+// ncname determines if s is a valid XML name without a colon
+func ncname(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z':
+		case i > 0 && (c == '-' || c == '.' || '0' <= c && c <= '9'):
+		case c >= utf8.RuneSelf:
+			return xmlparsename(s)
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// xmlparsename determines if s is a name without a colon accepted by encoding/xml, whose name
+// characters, those of the fourth edition of XML 1.0, are fewer than the letters and digits of Unicode
+func xmlparsename(s string) bool {
+	t, err := xml.NewDecoder(strings.NewReader("<" + s + "/>")).RawToken()
+	e, ok := t.(xml.StartElement)
+	return err == nil && ok && len(e.Attr) == 0 && e.Name.Space == "" && e.Name.Local == s
+}
+
+// attrescape escapes quotes, angle brackets and ampersands for use in an attribute value.
+// Ampersands beginning a character or entity reference are left alone, so that
+// already escaped values are not escaped twice.
+func attrescape(s string) string {
+	s = xmlchars(s)
+	if !strings.ContainsAny(s, `"<>&`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			b.WriteString("&quot;")
+		case '<':
+			b.WriteString("&lt;")
+		case '>':
+			b.WriteString("&gt;")
+		case '&':
+			if entity(s[i:]) {
+				b.WriteByte(c)
+			} else {
+				b.WriteString("&amp;")
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// entity determines if s begins with a reference to a character allowed in XML 1.0,
+// or to one of the predefined entities, the only ones a document without a DTD may use
+func entity(s string) bool {
+	end := strings.IndexByte(s, ';')
+	if end < 2 {
+		return false
+	}
+	ref := s[1:end]
+	switch {
+	case strings.HasPrefix(ref, "#x"):
+		r, err := strconv.ParseUint(ref[2:], 16, 32)
+		return err == nil && xmlchar(rune(r))
+	case strings.HasPrefix(ref, "#"):
+		r, err := strconv.ParseUint(ref[1:], 10, 32)
+		return err == nil && xmlchar(rune(r))
+	}
+	switch ref {
+	case "amp", "lt", "gt", "quot", "apos":
+		return true
+	}
+	return false
+}
+
+// parsestyle splits a style string into its property name and value pairs
+func parsestyle(s string) [][2]string {
+	var decl [][2]string
+	for _, d := range strings.Split(s, ";") {
+		n := strings.Index(d, ":")
+		if n < 0 {
+			continue
+		}
+		decl = append(decl, [2]string{strings.TrimSpace(d[:n]), strings.TrimSpace(d[n+1:])})
+	}
+	return decl
+}
+
+// formatstyle makes a style string from property name and value pairs
+func formatstyle(decl [][2]string) string {
+	p := make([]string, len(decl))
+	for i, d := range decl {
+		p[i] = d[0] + ":" + d[1]
+	}
+	return strings.Join(p, ";")
+}
+
+// pp returns a series of polygon points. If the coordinates differ in length,
+// ErrMismatchedPoints is latched, and the longer are truncated to the shorter.
 func (svg *SVG) pp(x []int, y []int, tag string) {
-	svg.print(tag)
 	if len(x) != len(y) {
-		svg.print(" ")
-		return
+		svg.latch(ErrMismatchedPoints)
+		if len(x) > len(y) {
+			x = x[:len(y)]
+		} else {
+			y = y[:len(x)]
+		}
 	}
-	lx := len(x) - 1
-	for i := 0; i < lx; i++ {
-		svg.print(coord(x[i], y[i]) + " ")
+	svg.print(tag)
+	for i := range x {
+		if i > 0 {
+			svg.print(" ")
+		}
+		svg.print(coord(x[i], y[i]))
 	}
-	svg.print(coord(x[lx], y[lx]))
 }
 
 // endstyle modifies an SVG object, with either a series of name="value" pairs,
-// or a single string containing a style
+// or a single string containing a style. Style fragments, including style attributes,
+// are merged into one style attribute, in place of the first.
 func endstyle(s []string, endtag string) string {
-	if len(s) > 0 {
-		nv := ""
-		for i := 0; i < len(s); i++ {
-			if strings.Index(s[i], "=") > 0 {
-				nv += (s[i]) + " "
+	var nv, decl []string
+	at := -1
+	addstyle := func(v string) {
+		if v = strings.Trim(strings.TrimSpace(v), ";"); v == "" {
+			return
+		}
+		if at < 0 {
+			at = len(nv)
+			nv = append(nv, "")
+		}
+		decl = append(decl, v)
+	}
+	for _, v := range s {
+		if strings.HasPrefix(v, rawmark) {
+			nv = append(nv, v[len(rawmark):])
+			continue
+		}
+		a, ok := parseattrs(v)
+		if !ok || strings.Index(v, "=") <= 0 {
+			addstyle(v)
+			continue
+		}
+		for _, p := range a {
+			if p[0] == "style" {
+				addstyle(p[1])
 			} else {
-				nv += style(s[i]) + " "
+				nv = append(nv, formatattrs([][2]string{p}))
 			}
 		}
-		return nv + endtag
+	}
+	if len(nv) == 0 {
+		return endtag
+	}
+	if at >= 0 {
+		nv[at] = style(strings.Join(decl, ";"))
+	}
+	return strings.Join(nv, " ") + endtag
+}
+
+// endattrs ends a tag begun without a trailing space, with the attributes s, if any
+func (svg *SVG) endattrs(s []string, endtag string) string {
+	if e := svg.endstyle(s, endtag); e != endtag {
+		return " " + e
 	}
 	return endtag
+}
+
+// clone makes a canvas writing to w, configured like svg and sharing its identifiers
+func (svg *SVG) clone(w io.Writer) *SVG {
+	if svg.ids == nil {
+		svg.ids = new(int64)
+	}
+	return &SVG{Writer: w, profile: svg.profile, contrast: svg.contrast, strict: svg.strict, state: svg.state, ids: svg.ids,
+		nonce: svg.nonce, clock: svg.clock, deterministic: svg.deterministic, precision: svg.precision, svg2: svg.svg2,
+		rounding: svg.rounding, metrics: svg.metrics}
+}
+
+// merge adds the warnings, errors, element counts and open containers of a clone made by clone
+func (svg *SVG) merge(g *SVG) {
+	if svg.elements == nil {
+		svg.elements = make(map[string]int)
+	}
+	for k, v := range g.elements {
+		svg.elements[k] += v
+	}
+	if svg.blocks == nil && len(g.blocks) > 0 {
+		svg.blocks = make(map[string]int)
+	}
+	for k, v := range g.blocks {
+		svg.blocks[k] += v
+	}
+	svg.warnings = append(svg.warnings, g.warnings...)
+	svg.open = append(svg.open, g.open...)
+	svg.openids = append(svg.openids, g.openids...)
+	svg.stray = append(svg.stray, g.stray...)
+	svg.latch(g.err)
+}
 
+// push records the opening of a container element
+func (svg *SVG) push(tag string) {
+	switch {
+	case svg.tee == nil:
+	case tag == "text":
+		svg.tee.hold()
+	default:
+		svg.tee.reset()
+	}
+	svg.open = append(svg.open, tag)
+	svg.openids = append(svg.openids, "")
+}
+
+// pop records the closing of a container element, noting closes that
+// do not match the innermost open container
+func (svg *SVG) pop(tag string) {
+	n := len(svg.open)
+	if n == 0 || svg.open[n-1] != tag {
+		svg.stray = append(svg.stray, tag)
+		return
+	}
+	svg.open = svg.open[:n-1]
+	svg.openids = svg.openids[:n-1]
+	if svg.tee != nil && tag == "text" {
+		svg.tee.release()
+	}
 }
 
-// tt creates a xml element, tag containing s
-func (svg *SVG) tt(tag string, s string) {
-	svg.print("<" + tag + ">")
-	xml.Escape(svg.Writer, []byte(s))
+// tt creates a xml element, tag containing s, with optional attributes
+func (svg *SVG) tt(tag string, s string, attrs ...string) {
+	svg.count(tag)
+	svg.print("<" + tag + svg.endattrs(attrs, ">"))
+	svg.escape(s)
 	svg.println("</" + tag + ">")
+	svg.textitem(tag, s, 0, 0, false)
 }
 
 // poly compiles the polygon element
 func (svg *SVG) poly(x []int, y []int, tag string, s ...string) {
+	svg.count(tag)
 	svg.pp(x, y, "<"+tag+" points=\"")
-	svg.print(`" ` + endstyle(s, "/>\n"))
+	svg.print(`"` + svg.endattrs(s, "/>\n"))
 }
 
 // onezero returns "0" or "1"
@@ -1010,10 +2631,220 @@ func pct(n uint8) uint8 {
 	return n
 }
 
-// islink determines if a string is a script reference
+// pctf returns a percentage, with the canvas precision. In strict mode, it is limited to 0-100.
+func (svg *SVG) pctf(v float64) string {
+	if svg.strict {
+		v = math.Max(0, math.Min(100, v))
+	}
+	return svg.num(v) + "%"
+}
+
+// num formats v with the canvas precision, without trailing zeros
+func (svg *SVG) num(v float64) string {
+	if !svg.precision.set {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	n := strconv.FormatFloat(v, 'f', svg.precision.places, 64)
+	if strings.Contains(n, ".") {
+		n = strings.TrimRight(strings.TrimRight(n, "0"), ".")
+	}
+	if n == "-0" {
+		n = "0"
+	}
+	return n
+}
+
+// SetPrecision sets the number of decimal places of fractional values written by methods taking
+// float64 coordinates and percentages; a negative number restores the default, as many as needed.
+func (svg *SVG) SetPrecision(places int) {
+	svg.precision.places, svg.precision.set = places, places >= 0
+}
+
+// islink determines if a string is a script reference: a URL with a common scheme, or
+// a fragment, path or protocol relative URL, which, unlike the text of a script or style,
+// has no spaces, quotes, braces, parentheses, semicolons or equal signs
 func islink(link string) bool {
-	return strings.HasPrefix(link, "http://") || strings.HasPrefix(link, "#") ||
-		strings.HasPrefix(link, "../") || strings.HasPrefix(link, "./")
+	for _, p := range []string{"http://", "https://", "file://", "data:", "mailto:"} {
+		if strings.HasPrefix(link, p) {
+			return true
+		}
+	}
+	return !strings.ContainsAny(link, " \t\r\n\"'{}();=<>") && strings.ContainsAny(link, "#./")
+}
+
+// cdatachunks writes the chunks, one after another, safely within a CDATA section.
+// The end of each chunk that may form part of "]]>", or of a character, with the next
+// is held back until the next is written, so chunks are not joined.
+func (svg *SVG) cdatachunks(chunks []string) {
+	held := ""
+	for _, c := range chunks {
+		s := held + c
+		n := len(s) - partialrune(s)
+		if n == len(s) {
+			for n > 0 && len(s)-n < 2 && s[n-1] == ']' {
+				n--
+			}
+		}
+		svg.print(cdata(s[:n]))
+		held = s[n:]
+	}
+	svg.print(cdata(held))
+}
+
+// partialrune returns the length of the incomplete UTF-8 encoded character ending s, if any
+func partialrune(s string) int {
+	for i := 1; i <= utf8.UTFMax-1 && i <= len(s); i++ {
+		if utf8.RuneStart(s[len(s)-i]) {
+			if utf8.FullRuneInString(s[len(s)-i:]) {
+				return 0
+			}
+			return i
+		}
+	}
+	return 0
+}
+
+// cdata makes text safe for a CDATA section, splitting any "]]>" across two sections
+func cdata(s string) string { return strings.ReplaceAll(xmlchars(s), "]]>", "]]]]><![CDATA[>") }
+
+// xmlchars replaces characters that are not allowed in XML 1.0, and invalid UTF-8, with U+FFFD
+func xmlchars(s string) string {
+	if validxml(s) {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		if xmlchar(r) {
+			return r
+		}
+		return utf8.RuneError
+	}, strings.ToValidUTF8(s, string(utf8.RuneError)))
+}
+
+// validxml determines if s is valid UTF-8 containing only characters allowed in XML 1.0
+func validxml(s string) bool {
+	for i := 0; i < len(s); {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && n == 1 || !xmlchar(r) {
+			return false
+		}
+		i += n
+	}
+	return true
+}
+
+// xmlchar determines if r is allowed in XML 1.0
+func xmlchar(r rune) bool {
+	return r == '\t' || r == '\n' || r == '\r' || r >= 0x20 && r <= 0xd7ff || r >= 0xe000 && r <= 0xfffd || r >= 0x10000 && r <= 0x10ffff
+}
+
+// checkchars latches an error in strict mode if s contains characters not allowed in XML 1.0,
+// which are replaced when written, naming the element being written
+func (svg *SVG) checkchars(s ...string) {
+	if !svg.strict {
+		return
+	}
+	for _, v := range s {
+		if !validxml(v) {
+			svg.latch(fmt.Errorf("%w in %s", ErrInvalidChar, svg.current))
+			return
+		}
+	}
+}
+
+// unitsattr returns the units attribute value for "user" or "obj", or their full names;
+// anything else is taken as the object bounding box
+func unitsattr(u string) string {
+	if u == "user" || u == "userSpaceOnUse" {
+		return "userSpaceOnUse"
+	}
+	return "objectBoundingBox"
+}
+
+// markerorient determines if orient is a marker orientation: auto, auto-start-reverse,
+// or an angle, in degrees or with a unit
+func markerorient(orient string) bool {
+	if orient == "auto" || orient == "auto-start-reverse" {
+		return true
+	}
+	for _, u := range []string{"deg", "grad", "rad", "turn"} {
+		if strings.HasSuffix(orient, u) {
+			orient = orient[:len(orient)-len(u)]
+			break
+		}
+	}
+	a, err := strconv.ParseFloat(orient, 64)
+	return err == nil && !math.IsInf(a, 0) && !math.IsNaN(a)
+}
+
+// markerunits returns the markerUnits value for "stroke" or "user", or their full names;
+// or empty, for others
+func markerunits(u string) string {
+	switch u {
+	case "stroke", "strokeWidth":
+		return "strokeWidth"
+	case "user", "userSpaceOnUse":
+		return "userSpaceOnUse"
+	}
+	return ""
+}
+
+// unitsopt returns the attribute name for the units u, "user" or "obj", or their full names,
+// preceded by a space; or empty, for empty or invalid units, warning of the latter
+func (svg *SVG) unitsopt(name, u string) string {
+	switch u {
+	case "":
+		return ""
+	case "user", "userSpaceOnUse", "obj", "objectBoundingBox":
+		return fmt.Sprintf(` %s="%s"`, name, unitsattr(u))
+	}
+	svg.warn("invalid %s %q omitted", name, u)
+	return ""
+}
+
+// unsafelink determines if, in strict mode, link should be rejected because of its scheme,
+// latching ErrUnsafeLink if so. Fragments, relative references, http, https and data are allowed.
+func (svg *SVG) unsafelink(link string) bool {
+	if !svg.strict {
+		return false
+	}
+	switch linkscheme(link) {
+	case "", "http", "https", "data":
+		return false
+	}
+	svg.latch(ErrUnsafeLink)
+	return true
+}
+
+// linkscheme returns the lower case scheme of a URI reference, or "" for a relative reference.
+// Leading spaces and control characters, which browsers ignore, are skipped.
+func linkscheme(link string) string {
+	link = strings.TrimLeftFunc(link, func(r rune) bool { return r <= ' ' })
+	for i := 0; i < len(link); i++ {
+		c := link[i]
+		switch {
+		case c == ':':
+			if i == 0 {
+				return ""
+			}
+			return strings.ToLower(link[:i])
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case i > 0 && ('0' <= c && c <= '9' || c == '+' || c == '-' || c == '.'):
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// unitcolor scales a color component to the range 0-1, to three places
+func unitcolor(c uint8) float64 { return math.Round(float64(c)/255*1000) / 1000 }
+
+// uid returns an identifier, beginning with prefix, that is unique within the document
+func (svg *SVG) uid(prefix string) string {
+	if svg.ids == nil {
+		svg.ids = new(int64)
+	}
+	return fmt.Sprintf("%s-%d", prefix, atomic.AddInt64(svg.ids, 1))
 }
 
 // group returns a group element
@@ -1047,7 +2878,12 @@ func ptag(x int, y int) string { return fmt.Sprintf(`<path d="M%s`, coord(x, y))
 func loc(x int, y int) string { return fmt.Sprintf(`x="%d" y="%d"`, x, y) }
 
 // href returns the href name and attribute
-func href(s string) string { return fmt.Sprintf(`xlink:href="%s"`, s) }
+func (svg *SVG) href(s string) string {
+	if svg.svg2 {
+		return `href="` + attrescape(s) + `"`
+	}
+	return `xlink:href="` + attrescape(s) + `"`
+}
 
 // dim returns the dimension string (x, y coordinates and width, height)
 func dim(x int, y int, w int, h int) string {
@@ -0,0 +1,863 @@
+package svg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// render returns the document drawn by draw on a 100x100 canvas
+func render(t *testing.T, draw func(*SVG)) string {
+	t.Helper()
+	canvas := NewBuffer()
+	canvas.Start(100, 100)
+	draw(canvas)
+	canvas.End()
+	if err := canvas.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	return canvas.String()
+}
+
+// wellformed fails the test if doc is not well formed XML
+func wellformed(t *testing.T, doc string) {
+	t.Helper()
+	d := xml.NewDecoder(strings.NewReader(doc))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("%v in\n%s", err, doc)
+		}
+	}
+}
+
+// element returns the element beginning with tag in doc
+func element(doc, tag string) string {
+	i := strings.Index(doc, tag)
+	if i < 0 {
+		return ""
+	}
+	return doc[i : i+strings.Index(doc[i:], ">")+1]
+}
+
+func TestNewNilWriter(t *testing.T) {
+	for name, canvas := range map[string]*SVG{
+		"New":            New(nil),
+		"NewBuffered":    NewBuffered(nil, 4096),
+		"NewWithOptions": NewWithOptions(nil, Options{Strict: true}),
+	} {
+		if err := canvas.Err(); err != ErrNilWriter {
+			t.Errorf("%s(nil).Err() = %v, want ErrNilWriter", name, err)
+		}
+		canvas.Start(100, 100)
+		canvas.Circle(50, 50, 10)
+		canvas.End()
+		if err := canvas.Err(); err != ErrNilWriter {
+			t.Errorf("%s(nil).Err() after drawing = %v, want ErrNilWriter", name, err)
+		}
+	}
+}
+
+func TestStrictNotStarted(t *testing.T) {
+	canvas := NewBuffer()
+	canvas.SetStrict(true)
+	canvas.Circle(50, 50, 10)
+	err := canvas.Err()
+	if !errors.Is(err, ErrNotStarted) {
+		t.Fatalf("Err() = %v, want ErrNotStarted", err)
+	}
+	var le *LifecycleError
+	if !errors.As(err, &le) || le.Op != "circle" {
+		t.Errorf("Err() = %#v, want a LifecycleError for circle", err)
+	}
+
+	canvas = NewBuffer()
+	canvas.SetStrict(true)
+	canvas.Start(100, 100)
+	canvas.End()
+	canvas.Circle(50, 50, 10)
+	if err := canvas.Err(); !errors.Is(err, ErrEnded) {
+		t.Errorf("Err() after End = %v, want ErrEnded", err)
+	}
+}
+
+func TestLenientNotStarted(t *testing.T) {
+	canvas := NewBuffer()
+	canvas.SetLenient(true)
+	canvas.Circle(50, 50, 10)
+	canvas.End()
+	if err := canvas.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	doc := canvas.String()
+	if !strings.Contains(doc, `<svg width="100%" height="100%"`) || !strings.Contains(doc, `<circle cx="50" cy="50" r="10"/>`) {
+		t.Errorf("lenient drawing before Start did not begin the document:\n%s", doc)
+	}
+	wellformed(t, doc)
+}
+
+// unbalanced opens three nested groups and a mask, without ending them
+func unbalanced(canvas *SVG) {
+	canvas.Gid("outer")
+	canvas.Gstyle("fill:red")
+	canvas.Translate(10, 10)
+	canvas.Mask("m", 0, 0, 50, 50)
+	canvas.Rect(0, 0, 50, 50, "fill:white")
+}
+
+func TestCloseAll(t *testing.T) {
+	closers := "<rect x=\"0\" y=\"0\" width=\"50\" height=\"50\" style=\"fill:white\"/>\n</mask>\n</g>\n</g>\n</g>\n"
+	canvas := NewBuffer()
+	canvas.Start(100, 100)
+	unbalanced(canvas)
+	if got, want := strings.Join(canvas.OpenElements(), " "), "g g g mask"; got != want {
+		t.Errorf("OpenElements() = %s, want %s", got, want)
+	}
+	canvas.CloseAll()
+	if open := canvas.OpenElements(); len(open) != 0 {
+		t.Errorf("OpenElements() = %q after CloseAll", open)
+	}
+	if doc := canvas.String(); !strings.HasSuffix(doc, closers) {
+		t.Errorf("closed\n%s\nwant it to end with\n%s", doc, closers)
+	}
+	canvas.CloseAll()
+	if err := canvas.EndChecked(); err != nil {
+		t.Errorf("EndChecked() = %v", err)
+	}
+	doc := canvas.String()
+	if !strings.HasSuffix(doc, closers+"</svg>\n") {
+		t.Errorf("document itself closed by CloseAll, or closers repeated, in\n%s", doc)
+	}
+	wellformed(t, doc)
+
+	lenient := NewBuffer()
+	lenient.SetLenient(true)
+	lenient.Start(100, 100)
+	unbalanced(lenient)
+	lenient.End()
+	if doc := lenient.String(); !strings.HasSuffix(doc, closers+"</svg>\n") {
+		t.Errorf("lenient End closed\n%s", doc)
+	}
+	if w := lenient.Warnings(); len(w) != 1 || w[0] != "End closed 4 open elements" {
+		t.Errorf("lenient End warnings %q", w)
+	}
+}
+
+func TestEndChecked(t *testing.T) {
+	for _, c := range []struct {
+		name        string
+		draw        func(*SVG)
+		open, stray []string
+	}{
+		{"balanced", func(canvas *SVG) {
+			canvas.Gstyle("fill:red")
+			canvas.Def()
+			canvas.Mask("m", 0, 0, 10, 10)
+			canvas.MaskEnd()
+			canvas.DefEnd()
+			canvas.Textspan(10, 10, "a")
+			canvas.Span("b")
+			canvas.TextEnd()
+			canvas.Gend()
+		}, nil, nil},
+		{"unclosed", func(canvas *SVG) {
+			canvas.Gid("outer")
+			canvas.Link("#top", "top")
+			canvas.ClipPath(`id="c"`)
+			canvas.Rect(0, 0, 10, 10)
+		}, []string{"g", "a", "clipPath"}, nil},
+		{"stray", func(canvas *SVG) {
+			canvas.Gstyle("fill:red")
+			canvas.Gend()
+			canvas.Gend()
+		}, nil, []string{"g"}},
+		{"mismatched", func(canvas *SVG) {
+			canvas.Gstyle("fill:red")
+			canvas.Mask("m", 0, 0, 10, 10)
+			canvas.Gend()
+		}, []string{"g", "mask"}, []string{"g"}},
+	} {
+		var checked, plain strings.Builder
+		canvas := New(&checked)
+		canvas.Start(100, 100)
+		c.draw(canvas)
+		err := canvas.EndChecked()
+		unchecked := New(&plain)
+		unchecked.Start(100, 100)
+		c.draw(unchecked)
+		unchecked.End()
+		if checked.String() != plain.String() {
+			t.Errorf("%s: EndChecked wrote\n%s\nEnd wrote\n%s", c.name, checked.String(), plain.String())
+		}
+		if c.open == nil && c.stray == nil {
+			if err != nil {
+				t.Errorf("%s: EndChecked() = %v", c.name, err)
+			}
+			wellformed(t, checked.String())
+			continue
+		}
+		var ue *UnbalancedError
+		if !errors.As(err, &ue) {
+			t.Errorf("%s: EndChecked() = %v, want an UnbalancedError", c.name, err)
+			continue
+		}
+		if fmt.Sprint(ue.Open) != fmt.Sprint(c.open) || fmt.Sprint(ue.Stray) != fmt.Sprint(c.stray) {
+			t.Errorf("%s: open %q and stray %q, want %q and %q", c.name, ue.Open, ue.Stray, c.open, c.stray)
+		}
+	}
+
+	canvas := New(new(strings.Builder))
+	canvas.Start(100, 100)
+	canvas.Gstyle("fill:red")
+	canvas.Mask("m", 0, 0, 10, 10)
+	canvas.Gend()
+	want := "svg: unclosed g, mask; unmatched end of g"
+	if err := canvas.EndChecked(); err == nil || err.Error() != want {
+		t.Errorf("EndChecked() = %v, want %s", err, want)
+	}
+}
+
+func TestMarkerFullArrowhead(t *testing.T) {
+	doc := render(t, func(canvas *SVG) {
+		canvas.Def()
+		canvas.MarkerFull("arrow", 10, 5, 6, 6, "auto-start-reverse", "stroke", "0 0 10 10")
+		canvas.Path("M0,0L10,5L0,10z")
+		canvas.MarkerEnd()
+		canvas.DefEnd()
+		canvas.Line(10, 10, 90, 90, "stroke:black;marker-end:url(#arrow)")
+	})
+	for _, want := range []string{
+		`<marker id="arrow" refX="10" refY="5" markerWidth="6" markerHeight="6" orient="auto-start-reverse" markerUnits="strokeWidth" viewBox="0 0 10 10">` + "\n",
+		`<line x1="10" y1="10" x2="90" y2="90" style="stroke:black;marker-end:url(#arrow)"/>`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("%s\nmissing from\n%s", want, doc)
+		}
+	}
+	wellformed(t, doc)
+}
+
+func TestMarkerFullOmitted(t *testing.T) {
+	canvas := NewBuffer()
+	canvas.Start(100, 100)
+	canvas.Marker("plain", 1, 2, 3, 4)
+	canvas.MarkerEnd()
+	canvas.MarkerFull("bad", 1, 2, 3, 4, "sideways", "obj", "")
+	canvas.MarkerEnd()
+	canvas.End()
+	doc := canvas.String()
+	for _, want := range []string{
+		`<marker id="plain" refX="1" refY="2" markerWidth="3" markerHeight="4">` + "\n",
+		`<marker id="bad" refX="1" refY="2" markerWidth="3" markerHeight="4">` + "\n",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("%s\nmissing from\n%s", want, doc)
+		}
+	}
+	if w := canvas.Warnings(); len(w) != 2 {
+		t.Errorf("Warnings() = %q, want orientation and units warnings", w)
+	}
+}
+
+func TestFeColorMatrixLuminanceToAlpha(t *testing.T) {
+	fs := Filterspec{In: "SourceGraphic", Result: "alpha"}
+	for name, draw := range map[string]func(*SVG){
+		"FeColorMatrixLuminanceToAlpha": func(c *SVG) { c.FeColorMatrixLuminanceToAlpha(fs) },
+		"FeColorMatrixLuminence":        func(c *SVG) { c.FeColorMatrixLuminence(fs) },
+	} {
+		doc := render(t, draw)
+		want := `<feColorMatrix in="SourceGraphic" result="alpha" type="luminanceToAlpha"/>`
+		if !strings.Contains(doc, want) {
+			t.Errorf("%s: %s\nmissing from\n%s", name, want, doc)
+		}
+	}
+}
+
+func TestFeDisplacementMapChannels(t *testing.T) {
+	for _, c := range []struct{ x, y, want string }{
+		{"R", "G", `xChannelSelector="R" yChannelSelector="G"`},
+		{"b", "a", `xChannelSelector="B" yChannelSelector="A"`},
+		{"green", "blue", `xChannelSelector="G" yChannelSelector="B"`},
+		{"Alpha", "Red", `xChannelSelector="A" yChannelSelector="R"`},
+		{"bogus", "bogus", `xChannelSelector="R" yChannelSelector="R"`},
+	} {
+		doc := render(t, func(canvas *SVG) { canvas.FeDisplacementMap(Filterspec{}, 10, c.x, c.y) })
+		if want := `<feDisplacementMap scale="10" ` + c.want + `/>`; !strings.Contains(doc, want) {
+			t.Errorf("FeDisplacementMap(%q, %q): %s\nmissing from\n%s", c.x, c.y, want, doc)
+		}
+	}
+}
+
+func TestFeTileChain(t *testing.T) {
+	for name, tile := range map[string]func(*SVG){
+		"in":         func(c *SVG) { c.FeTile(Filterspec{Result: "tiled"}, "blur") },
+		"Filterspec": func(c *SVG) { c.FeTile(Filterspec{In: "blur", Result: "tiled"}, "") },
+		"both":       func(c *SVG) { c.FeTile(Filterspec{In: "SourceGraphic", Result: "tiled"}, "blur") },
+	} {
+		doc := render(t, func(canvas *SVG) {
+			canvas.Filter("tile")
+			canvas.FeGaussianBlur(Filterspec{In: "SourceGraphic", Result: "blur"}, 2, 2)
+			tile(canvas)
+			canvas.Fend()
+		})
+		got := element(doc, "<feTile")
+		if want := `<feTile in="blur" result="tiled"/>`; got != want {
+			t.Errorf("%s: %s, want %s", name, got, want)
+		}
+		if n := strings.Count(got, " in="); n != 1 {
+			t.Errorf("%s: in appears %d times in %s", name, n, got)
+		}
+		wellformed(t, doc)
+	}
+}
+
+func TestFeTurbulence(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		draw func(*SVG)
+		want string
+	}{
+		{"empty ftype", func(c *SVG) { c.FeTurbulence(Filterspec{}, "", 0.05, 0.05, 2, 1, false) },
+			`<feTurbulence type="turbulence" baseFrequency="0.05 0.05" numOctaves="2" seed="1" stitchTiles="noStitch"/>`},
+		{"fractal", func(c *SVG) { c.FeTurbulence(Filterspec{Result: "noise"}, "fractalNoise", 2.5, 0.125, 4, 0, true) },
+			`<feTurbulence result="noise" type="fractalNoise" baseFrequency="2.5 0.125" numOctaves="4" seed="0" stitchTiles="stitch"/>`},
+		{"negative", func(c *SVG) { c.FeTurbulence(Filterspec{}, "turbulence", -1, 0.001, 1, 3, false) },
+			`<feTurbulence type="turbulence" baseFrequency="0 0.001" numOctaves="1" seed="3" stitchTiles="noStitch"/>`},
+		{"fractional seed", func(c *SVG) { c.FeTurbulencef(Filterspec{}, "f", 0.3, 0.3, 1, 2.75, false) },
+			`<feTurbulence type="fractalNoise" baseFrequency="0.3 0.3" numOctaves="1" seed="2.75" stitchTiles="noStitch"/>`},
+	} {
+		doc := render(t, func(canvas *SVG) {
+			canvas.Filter("noise")
+			c.draw(canvas)
+			canvas.Fend()
+		})
+		if got := element(doc, "<feTurbulence"); got != c.want {
+			t.Errorf("%s: %s, want %s", c.name, got, c.want)
+		}
+		wellformed(t, doc)
+	}
+}
+
+func TestHostileAttributes(t *testing.T) {
+	shapes := map[string]func(*SVG, ...string){
+		"rect":   func(c *SVG, s ...string) { c.Rect(10, 10, 20, 20, s...) },
+		"circle": func(c *SVG, s ...string) { c.Circle(50, 50, 10, s...) },
+		"g":      func(c *SVG, s ...string) { c.Group(s...); c.Gend() },
+		"text":   func(c *SVG, s ...string) { c.Text(10, 90, "label", s...) },
+	}
+	for _, c := range []struct {
+		name  string
+		attrs []string
+		want  map[string]string
+	}{
+		{"style breakout", []string{`"><script>alert(1)</script>`}, map[string]string{"style": `"><script>alert(1)</script>`}},
+		{"style quote", []string{`fill:red" onload="alert(1)`}, map[string]string{"style": `fill:red" onload="alert(1)`}},
+		{"style ampersand", []string{`fill:url("#a") & <b>`}, map[string]string{"style": `fill:url("#a") & <b>`}},
+		{"value ampersand", []string{`fill="red" title="a & b"`}, map[string]string{"fill": "red", "title": "a & b"}},
+		{"value breakout", []string{`title='"><script>'`}, map[string]string{"title": `"><script>`}},
+		{"entities", []string{`title="&lt;&#38;&amp;"`}, map[string]string{"title": "<&&"}},
+	} {
+		for tag, draw := range shapes {
+			doc := render(t, func(canvas *SVG) { draw(canvas, c.attrs...) })
+			var found bool
+			d := xml.NewDecoder(strings.NewReader(doc))
+			for {
+				tok, err := d.Token()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("%s %s: %v in\n%s", c.name, tag, err, doc)
+				}
+				e, ok := tok.(xml.StartElement)
+				if !ok {
+					continue
+				}
+				if e.Name.Local == "script" {
+					t.Errorf("%s %s: script injected in\n%s", c.name, tag, doc)
+				}
+				if e.Name.Local != tag {
+					continue
+				}
+				found = true
+				got := make(map[string]string)
+				for _, a := range e.Attr {
+					got[a.Name.Local] = a.Value
+				}
+				for k, v := range c.want {
+					if got[k] != v {
+						t.Errorf("%s %s: %s=%q, want %q", c.name, tag, k, got[k], v)
+					}
+				}
+			}
+			if !found {
+				t.Errorf("%s: %s missing from\n%s", c.name, tag, doc)
+			}
+		}
+	}
+
+	raw := `data-raw="a&amp;b" data-quoted='x'`
+	for tag, draw := range shapes {
+		doc := render(t, func(canvas *SVG) { draw(canvas, Raw(raw)) })
+		if !strings.Contains(element(doc, "<"+tag+" "), " "+raw) {
+			t.Errorf("%s: Raw attributes changed in\n%s", tag, doc)
+		}
+		wellformed(t, doc)
+	}
+}
+
+// linkers draw an element referring to link, named by the element carrying the reference
+var linkers = map[string]func(*SVG, string){
+	"a":        func(c *SVG, link string) { c.Link(link, "title"); c.Circle(10, 10, 5); c.LinkEnd() },
+	"use":      func(c *SVG, link string) { c.Use(10, 10, link) },
+	"image":    func(c *SVG, link string) { c.Image(0, 0, 10, 10, link) },
+	"textPath": func(c *SVG, link string) { c.Textpath("along", link) },
+}
+
+// hrefs returns the href values of the elements named tag in doc, failing the test if doc is not well formed
+func hrefs(t *testing.T, doc, tag string) []string {
+	t.Helper()
+	var links []string
+	d := xml.NewDecoder(strings.NewReader(doc))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return links
+		}
+		if err != nil {
+			t.Fatalf("%v in\n%s", err, doc)
+		}
+		if e, ok := tok.(xml.StartElement); ok && e.Name.Local == tag {
+			for _, a := range e.Attr {
+				if a.Name.Local == "href" {
+					links = append(links, a.Value)
+				}
+			}
+		}
+	}
+}
+
+func TestHrefEscape(t *testing.T) {
+	for _, link := range []string{
+		`http://example.com/?q="quoted"`,
+		`http://example.com/?a=1&b=2`,
+		`http://example.com/?a=1&amp;b=2`,
+		`https://例え.jp/パス?q=ü#frag`,
+		`#id"/><script>alert(1)</script>`,
+	} {
+		for tag, draw := range linkers {
+			for _, svg2 := range []bool{false, true} {
+				var b strings.Builder
+				canvas := NewWithOptions(&b, Options{SVG2: svg2})
+				canvas.Start(100, 100)
+				draw(canvas, link)
+				canvas.End()
+				doc := b.String()
+				want := link
+				if strings.Contains(link, "&amp;") {
+					want = strings.ReplaceAll(link, "&amp;", "&")
+				}
+				if got := hrefs(t, doc, tag); len(got) != 1 || got[0] != want {
+					t.Errorf("%s, SVG2 %v: href %q, want %q, in\n%s", tag, svg2, got, want, doc)
+				}
+				if strings.Contains(doc, "<script") {
+					t.Errorf("%s, SVG2 %v: script injected in\n%s", tag, svg2, doc)
+				}
+			}
+		}
+	}
+}
+
+func TestStrictLinks(t *testing.T) {
+	for _, c := range []struct {
+		link string
+		safe bool
+	}{
+		{"http://example.com/a.svg", true},
+		{"HTTPS://example.com/a.svg#b", true},
+		{"data:image/png;base64,iVBORw0KGgo=", true},
+		{"#frag", true},
+		{"images/a.png", true},
+		{"../a.svg#b", true},
+		{"/a.svg", true},
+		{"javascript:alert(1)", false},
+		{" JavaScript:alert(1)", false},
+		{"\tjavascript:alert(1)", false},
+		{"vbscript:msgbox", false},
+		{"file:///etc/passwd", false},
+	} {
+		for tag, draw := range linkers {
+			var b strings.Builder
+			canvas := NewWithOptions(&b, Options{Strict: true})
+			canvas.Start(100, 100)
+			draw(canvas, c.link)
+			canvas.End()
+			doc := b.String()
+			got := hrefs(t, doc, tag)
+			switch err := canvas.Err(); {
+			case c.safe && err != nil:
+				t.Errorf("%s %q: Err() = %v", tag, c.link, err)
+			case c.safe && (len(got) != 1 || got[0] != c.link):
+				t.Errorf("%s %q: hrefs %q in\n%s", tag, c.link, got, doc)
+			case !c.safe && !errors.Is(err, ErrUnsafeLink):
+				t.Errorf("%s %q: Err() = %v, want ErrUnsafeLink", tag, c.link, err)
+			case !c.safe && strings.Contains(doc, strings.TrimSpace(c.link)):
+				t.Errorf("%s %q: link written in\n%s", tag, c.link, doc)
+			}
+		}
+	}
+}
+
+// embedded returns the text of the elements named tag in doc, failing the test if doc is not well formed
+func embedded(t *testing.T, doc, tag string) []string {
+	t.Helper()
+	var texts []string
+	d := xml.NewDecoder(strings.NewReader(doc))
+	in := false
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return texts
+		}
+		if err != nil {
+			t.Fatalf("%v in\n%s", err, doc)
+		}
+		switch e := tok.(type) {
+		case xml.StartElement:
+			if in = e.Name.Local == tag; in {
+				texts = append(texts, "")
+			}
+		case xml.CharData:
+			if in {
+				texts[len(texts)-1] += string(e)
+			}
+		case xml.EndElement:
+			in = false
+		}
+	}
+}
+
+func TestCDATARoundTrip(t *testing.T) {
+	for _, data := range [][]string{
+		{`var s = "a]]>b";`},
+		{"]]>"},
+		{"]]>]]>", "]]]>>"},
+		{`if (a[b[0]]>1) {}`},
+		{`var s = "a]]`, `>b";`},
+		{`var s = "a]`, `]>b";`},
+		{`var s = "a]`, `]`, `>b";`},
+		{"x]]", "", ">y"},
+		{"]", "]", ">", "]]", ">"},
+		{"é]]", ">ü", "\xe2\x82", "\xac]]>"},
+		{"plain", " text"},
+	} {
+		// the section, and the text around it, begin and end with a newline
+		want := "\n\n" + strings.Join(data, "") + "\n\n"
+		for tag, draw := range map[string]func(*SVG){
+			"script": func(c *SVG) { c.Script("application/javascript", data...) },
+			"style":  func(c *SVG) { c.Style("text/css", data...) },
+		} {
+			doc := render(t, draw)
+			if got := embedded(t, doc, tag); len(got) != 1 || got[0] != want {
+				t.Errorf("%s %q: parsed %q, want %q, from\n%s", tag, data, got, want, doc)
+			}
+		}
+	}
+}
+
+func TestScriptNonce(t *testing.T) {
+	doc := render(t, func(canvas *SVG) {
+		canvas.ScriptNonce("application/javascript", `r4nd"<`, "var a;")
+		canvas.Script("application/javascript", "var b;")
+		canvas.SetNonce("canvas")
+		canvas.Script("application/javascript", "var c;")
+		canvas.ScriptLink("application/javascript", "c.js")
+		canvas.ScriptNonce("application/javascript", "own", "var d;")
+		canvas.AtomicGroup(func(g *SVG) { g.Script("application/javascript", "var e;") })
+		canvas.ToggleLayer("grid", "Grid", true, func(*SVG) {})
+		canvas.LayerControls(0, 0)
+		canvas.Style("text/css", "circle { fill: red }")
+	})
+	for _, want := range []string{
+		`<script type="application/javascript" nonce="r4nd&quot;&lt;">` + "\n<![CDATA[\nvar a;",
+		`<script type="application/javascript">` + "\n<![CDATA[\nvar b;",
+		`<script type="application/javascript" nonce="canvas">` + "\n<![CDATA[\nvar c;",
+		`<script type="application/javascript" nonce="canvas" xlink:href="c.js"/>`,
+		`<script type="application/javascript" nonce="own">` + "\n<![CDATA[\nvar d;",
+		`<script type="application/javascript" nonce="canvas">` + "\n<![CDATA[\nvar e;",
+		`<script type="application/javascript" nonce="canvas">` + "\n<![CDATA[\n" + layerscript,
+		`<style type="text/css">`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("%s\nmissing from\n%s", want, doc)
+		}
+	}
+	wellformed(t, doc)
+}
+
+func TestNewSafeConcurrent(t *testing.T) {
+	const workers, draws = 8, 50
+	var b bytes.Buffer
+	canvas := NewSafe(&b)
+	canvas.Start(100, 100)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < draws; i++ {
+				id := fmt.Sprintf("w%d-%d", w, i)
+				canvas.Circle(w, i, 1, fmt.Sprintf(`id="c%s"`, id))
+				canvas.AtomicGroup(func(g *SVG) {
+					for j := 0; j < 3; j++ {
+						g.Rect(w, i, j, j, fmt.Sprintf(`class="%s"`, id))
+					}
+					g.ProgressBar(0, 0, 10, 2, 0.5, "green", "gray", true)
+				}, fmt.Sprintf(`id="g%s"`, id))
+			}
+		}(w)
+	}
+	wg.Wait()
+	canvas.End()
+	if err := canvas.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	var doc struct {
+		Circles []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"circle"`
+		Groups []struct {
+			ID    string `xml:"id,attr"`
+			Rects []struct {
+				Class string `xml:"class,attr"`
+			} `xml:"rect"`
+		} `xml:"g"`
+	}
+	if err := xml.Unmarshal(b.Bytes(), &doc); err != nil {
+		t.Fatalf("%v in\n%s", err, b.String())
+	}
+	if len(doc.Circles) != workers*draws || len(doc.Groups) != workers*draws {
+		t.Fatalf("%d circles and %d groups, want %d of each", len(doc.Circles), len(doc.Groups), workers*draws)
+	}
+	for _, g := range doc.Groups {
+		if len(g.Rects) != 3 {
+			t.Errorf("group %s holds %d rects, want 3", g.ID, len(g.Rects))
+		}
+		for _, r := range g.Rects {
+			if "g"+r.Class != g.ID {
+				t.Errorf("group %s holds a rect of %s", g.ID, r.Class)
+			}
+		}
+	}
+	clips := make(map[string]bool)
+	for _, m := range regexp.MustCompile(`<clipPath id="([^"]*)"`).FindAllStringSubmatch(b.String(), -1) {
+		if clips[m[1]] {
+			t.Errorf("clip path id %s generated twice", m[1])
+		}
+		clips[m[1]] = true
+	}
+	if n := canvas.Stats().Elements["circle"]; n != workers*draws {
+		t.Errorf("Stats().Elements[circle] = %d, want %d", n, workers*draws)
+	}
+}
+
+// circles draws n circles on canvas as a whole document
+func circles(canvas *SVG, n int) {
+	canvas.Start(1000, 1000)
+	for i := 0; i < n; i++ {
+		canvas.Circle(i%1000, i/1000, 5, "fill:red")
+	}
+	canvas.End()
+}
+
+func BenchmarkWriteFile(b *testing.B) {
+	for _, c := range []struct {
+		name string
+		make func(io.Writer) *SVG
+	}{
+		{"New", func(w io.Writer) *SVG { return New(w) }},
+		{"NewBuffered", func(w io.Writer) *SVG { return NewBuffered(w, 64*1024) }},
+	} {
+		b.Run(c.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				f, err := os.CreateTemp(b.TempDir(), "*.svg")
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+				canvas := c.make(f)
+				circles(canvas, 100000)
+				if err := canvas.Err(); err != nil {
+					b.Fatal(err)
+				}
+				b.StopTimer()
+				f.Close()
+				b.StartTimer()
+			}
+		})
+	}
+}
+
+// BenchmarkStats compares drawing a 50k element document with the instrumentation behind
+// Stats alone: counting the elements and bytes written by the same document.
+func BenchmarkStats(b *testing.B) {
+	const n = 50000
+	b.Run("document", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			circles(New(io.Discard), n)
+		}
+	})
+	b.Run("instrumentation", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			canvas := New(io.Discard)
+			canvas.Start(1000, 1000)
+			for j := 0; j < n; j++ {
+				canvas.count("circle")
+				canvas.nbytes += 48
+			}
+			canvas.End()
+		}
+	})
+}
+
+func FuzzText(f *testing.F) {
+	for _, s := range []string{"", "plain", "<&>\"'", "\x08\x00\x1f", "]]>", "\xff\xfe", "￾￿", `a="b"`, "fill:red;x=<", "&A0;&#0;", "\uaaaa=", "::="} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		canvas := NewBuffer()
+		canvas.Start(100, 100)
+		canvas.Title(s)
+		canvas.Desc(s)
+		canvas.Text(0, 10, s, s)
+		canvas.Textspan(0, 20, s)
+		canvas.Span(s, s)
+		canvas.TextEnd()
+		canvas.Circle(10, 10, 5, s)
+		canvas.LinkFull(s, s, s)
+		canvas.Image(0, 0, 10, 10, s)
+		canvas.LinkEnd()
+		canvas.Script("application/javascript", s)
+		canvas.Style("text/css", s)
+		canvas.End()
+		d := xml.NewDecoder(bytes.NewReader(canvas.Bytes()))
+		for {
+			_, err := d.Token()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				t.Fatalf("%v for %q in\n%s", err, s, canvas.String())
+			}
+		}
+	})
+}
+
+func TestClipShapes(t *testing.T) {
+	for name, clip := range map[string]func(*SVG) string{
+		"ClipRect":   func(c *SVG) string { return c.ClipRect("c", 10, 10, 50, 50) },
+		"ClipCircle": func(c *SVG) string { return c.ClipCircle("c", 50, 50, 25) },
+	} {
+		var ref string
+		doc := render(t, func(canvas *SVG) {
+			ref = clip(canvas)
+			canvas.Rect(0, 0, 100, 100, "clip-path:"+ref)
+		})
+		if ref != "url(#c)" {
+			t.Errorf("%s returned %q, want url(#c)", name, ref)
+		}
+		var parsed struct {
+			Defs []struct {
+				ClipPaths []struct {
+					ID string `xml:"id,attr"`
+				} `xml:"clipPath"`
+			} `xml:"defs"`
+			Rects []struct {
+				Style string `xml:"style,attr"`
+			} `xml:"rect"`
+		}
+		if err := xml.Unmarshal([]byte(doc), &parsed); err != nil {
+			t.Fatalf("%s: %v in\n%s", name, err, doc)
+		}
+		if len(parsed.Defs) != 1 || len(parsed.Defs[0].ClipPaths) != 1 || parsed.Defs[0].ClipPaths[0].ID != "c" {
+			t.Errorf("%s: clip path c not defined in\n%s", name, doc)
+		}
+		if len(parsed.Rects) != 1 || parsed.Rects[0].Style != "clip-path:url(#c)" {
+			t.Errorf("%s: reference not applied in\n%s", name, doc)
+		}
+	}
+}
+
+// defsdepth returns the greatest depth of nested defs elements in doc, and the number of them
+func defsdepth(t *testing.T, doc string) (depth, n int) {
+	t.Helper()
+	d := xml.NewDecoder(strings.NewReader(doc))
+	level := 0
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return depth, n
+		}
+		if err != nil {
+			t.Fatalf("%v in\n%s", err, doc)
+		}
+		switch e := tok.(type) {
+		case xml.StartElement:
+			if e.Name.Local == "defs" {
+				level++
+				n++
+				if level > depth {
+					depth = level
+				}
+			}
+		case xml.EndElement:
+			if e.Name.Local == "defs" {
+				level--
+			}
+		}
+	}
+}
+
+func TestInDefs(t *testing.T) {
+	for name, helper := range map[string]func(*SVG){
+		"ClipRect":     func(c *SVG) { c.ClipRect("c", 0, 0, 10, 10) },
+		"ClipCircle":   func(c *SVG) { c.ClipCircle("c", 5, 5, 5) },
+		"Duotone":      func(c *SVG) { c.Duotone("d", "#000080", "#ffff00") },
+		"DuotoneImage": func(c *SVG) { c.DuotoneImage(0, 0, 10, 10, "a.png", "#000080", "#ffff00") },
+	} {
+		for _, inside := range []bool{false, true} {
+			doc := render(t, func(canvas *SVG) {
+				if canvas.InDefs() {
+					t.Errorf("%s: InDefs() before Def", name)
+				}
+				if inside {
+					canvas.Def()
+					if !canvas.InDefs() {
+						t.Errorf("%s: InDefs() false after Def", name)
+					}
+					helper(canvas)
+					canvas.DefEnd()
+					return
+				}
+				helper(canvas)
+				if canvas.InDefs() {
+					t.Errorf("%s: definition block left open", name)
+				}
+			})
+			if depth, n := defsdepth(t, doc); depth != 1 || n != 1 {
+				t.Errorf("%s inside Def %v: %d defs nested %d deep, want one in\n%s", name, inside, n, depth, doc)
+			}
+		}
+	}
+}
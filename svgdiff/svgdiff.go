@@ -0,0 +1,337 @@
+// Package svgdiff compares SVG documents element by element, for reviewing changes to generated output
+package svgdiff
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Kind is the kind of a change between documents
+type Kind int
+
+const (
+	Added       Kind = iota // element present only in the second document
+	Removed                 // element present only in the first document
+	Moved                   // element with an id at a different location
+	AttrAdded               // attribute present only in the second document
+	AttrRemoved             // attribute present only in the first document
+	AttrChanged             // attribute with a different value
+	TextChanged             // element with different character data
+	LineChanged             // line differing in documents that could not be parsed
+)
+
+var kindnames = [...]string{"added", "removed", "moved", "attribute added", "attribute removed",
+	"attribute changed", "text changed", "line changed"}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindnames) {
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+	return kindnames[k]
+}
+
+// Change describes a single difference between documents.
+// Path locates the element, by id if it has one, otherwise by position in the second document,
+// or the first, for removed elements;
+// Attr names the attribute for attribute changes; Old and New hold the differing values.
+type Change struct {
+	Kind     Kind
+	Path     string
+	Attr     string
+	Old, New string
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case Added, Removed:
+		return fmt.Sprintf("%s: %s", c.Path, c.Kind)
+	case Moved:
+		return fmt.Sprintf("%s: moved from %s to %s", c.Path, c.Old, c.New)
+	case AttrAdded:
+		return fmt.Sprintf("%s: %s added %q", c.Path, c.Attr, c.New)
+	case AttrRemoved:
+		return fmt.Sprintf("%s: %s removed %q", c.Path, c.Attr, c.Old)
+	case AttrChanged:
+		return fmt.Sprintf("%s: %s %q -> %q", c.Path, c.Attr, c.Old, c.New)
+	}
+	return fmt.Sprintf("%s: %s %q -> %q", c.Path, c.Kind, c.Old, c.New)
+}
+
+// Summary returns a human readable summary of changes, one per line
+func Summary(changes []Change) string {
+	if len(changes) == 0 {
+		return "no changes\n"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d change(s)\n", len(changes))
+	for _, c := range changes {
+		b.WriteString(c.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// element is a parsed element, with its position in the document
+type element struct {
+	tag      string
+	id       string
+	pos      string // path by position, such as /svg[1]/g[2]/rect[1]
+	attrs    map[string]string
+	text     string
+	children []*element
+}
+
+// key returns the path reported for the element: its id reference if it has an id, otherwise its position
+func (e *element) key() string {
+	if e.id != "" {
+		return "#" + e.id
+	}
+	return e.pos
+}
+
+// token returns what identifies the element among its siblings: its id if it has one, otherwise its tag
+func (e *element) token() string {
+	if e.id != "" {
+		return "#" + e.id
+	}
+	return e.tag
+}
+
+// Compare reports the changes from document a to document b. Elements with an id are matched by id,
+// wherever they are; the children of matched elements are aligned like a longest common subsequence
+// of their tags and ids, preferring siblings that are the same, so that an insertion or removal
+// is reported alone, without disturbing the siblings that follow it. If either document cannot be parsed, Compare falls back to
+// a line by line comparison, returning those changes along with the parse error.
+func Compare(a, b []byte) ([]Change, error) {
+	ra, err := parse(a)
+	if err != nil {
+		return lines(a, b), err
+	}
+	rb, err := parse(b)
+	if err != nil {
+		return lines(a, b), err
+	}
+	c := comparison{ida: ids(ra, nil), idb: ids(rb, nil)}
+	if ra.token() != rb.token() {
+		c.changes = append(c.changes, Change{Kind: Removed, Path: ra.key()}, Change{Kind: Added, Path: rb.key()})
+		return c.changes, nil
+	}
+	c.element(ra, rb)
+	return c.changes, nil
+}
+
+// comparison accumulates the changes between documents, with the elements of each by id
+type comparison struct {
+	ida, idb map[string]*element
+	changes  []Change
+}
+
+// element compares the matched elements a and b, and their children
+func (c *comparison) element(a, b *element) {
+	c.changes = append(c.changes, attrchanges(b.key(), a.attrs, b.attrs)...)
+	if a.text != b.text {
+		c.changes = append(c.changes, Change{Kind: TextChanged, Path: b.key(), Old: a.text, New: b.text})
+	}
+	c.children(a.children, b.children)
+}
+
+// children aligns the children of matched elements, and compares them
+func (c *comparison) children(ca, cb []*element) {
+	// best[i][j] is the weight of the best alignment of ca[i:] and cb[j:]
+	best := make([][]int, len(ca)+1)
+	for i := range best {
+		best[i] = make([]int, len(cb)+1)
+	}
+	for i := len(ca) - 1; i >= 0; i-- {
+		for j := len(cb) - 1; j >= 0; j-- {
+			best[i][j] = max(best[i+1][j], best[i][j+1])
+			if w := weight(ca[i], cb[j]); w > 0 {
+				best[i][j] = max(best[i][j], w+best[i+1][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(ca) || j < len(cb) {
+		switch {
+		case i < len(ca) && j < len(cb) && weight(ca[i], cb[j]) > 0 && best[i][j] == weight(ca[i], cb[j])+best[i+1][j+1]:
+			c.element(ca[i], cb[j])
+			i, j = i+1, j+1
+		case i < len(ca) && best[i][j] == best[i+1][j]:
+			c.removed(ca[i])
+			i++
+		default:
+			c.added(cb[j])
+			j++
+		}
+	}
+}
+
+// weight is the value of aligning siblings a and b: none if they differ in tag or id,
+// more if their attributes and text are the same than if not
+func weight(a, b *element) int {
+	switch {
+	case a.token() != b.token():
+		return 0
+	case a.text == b.text && reflect.DeepEqual(a.attrs, b.attrs):
+		return 2
+	}
+	return 1
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// added reports an element of b not aligned with one of a: moved, if a has an element with its id
+func (c *comparison) added(e *element) {
+	old, ok := c.ida[e.id]
+	if e.id == "" || !ok {
+		c.changes = append(c.changes, Change{Kind: Added, Path: e.key()})
+		return
+	}
+	c.changes = append(c.changes, Change{Kind: Moved, Path: e.key(), Old: old.pos, New: e.pos})
+	c.element(old, e)
+}
+
+// removed reports an element of a not aligned with one of b, unless it moved
+func (c *comparison) removed(e *element) {
+	if _, ok := c.idb[e.id]; e.id != "" && ok {
+		return
+	}
+	c.changes = append(c.changes, Change{Kind: Removed, Path: e.key()})
+}
+
+// ids adds the elements with an id under e, and e, to m, keeping the first of any duplicates
+func ids(e *element, m map[string]*element) map[string]*element {
+	if m == nil {
+		m = make(map[string]*element)
+	}
+	if _, dup := m[e.id]; e.id != "" && !dup {
+		m[e.id] = e
+	}
+	for _, c := range e.children {
+		ids(c, m)
+	}
+	return m
+}
+
+// attrchanges compares the attributes of matched elements, in attribute name order
+func attrchanges(path string, a, b map[string]string) []Change {
+	names := make([]string, 0, len(a)+len(b))
+	for n := range a {
+		names = append(names, n)
+	}
+	for n := range b {
+		if _, ok := a[n]; !ok {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	var changes []Change
+	for _, n := range names {
+		av, ina := a[n]
+		bv, inb := b[n]
+		switch {
+		case !ina:
+			changes = append(changes, Change{Kind: AttrAdded, Path: path, Attr: n, New: bv})
+		case !inb:
+			changes = append(changes, Change{Kind: AttrRemoved, Path: path, Attr: n, Old: av})
+		case av != bv:
+			changes = append(changes, Change{Kind: AttrChanged, Path: path, Attr: n, Old: av, New: bv})
+		}
+	}
+	return changes
+}
+
+// parse returns the root element of a document
+func parse(doc []byte) (*element, error) {
+	type level struct {
+		el     *element
+		counts map[string]int
+	}
+	var root *element
+	stack := []level{{el: &element{}, counts: map[string]int{}}}
+	d := xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		top := &stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			top.counts[t.Name.Local]++
+			e := &element{tag: t.Name.Local, attrs: make(map[string]string, len(t.Attr)),
+				pos: fmt.Sprintf("%s/%s[%d]", top.el.pos, t.Name.Local, top.counts[t.Name.Local])}
+			for _, a := range t.Attr {
+				e.attrs[attrname(a.Name)] = a.Value
+				if a.Name.Local == "id" && a.Name.Space == "" {
+					e.id = a.Value
+				}
+			}
+			top.el.children = append(top.el.children, e)
+			if root == nil {
+				root = e
+			}
+			stack = append(stack, level{el: e, counts: map[string]int{}})
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 1 {
+				top.el.text += strings.TrimSpace(string(t))
+			}
+		}
+	}
+	if root == nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return root, nil
+}
+
+// attrname returns an attribute name, with the common namespaces shown by their usual prefix
+func attrname(n xml.Name) string {
+	switch n.Space {
+	case "":
+		return n.Local
+	case "http://www.w3.org/1999/xlink":
+		return "xlink:" + n.Local
+	case "http://www.w3.org/XML/1998/namespace":
+		return "xml:" + n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+// lines compares documents line by line
+func lines(a, b []byte) []Change {
+	la := strings.Split(string(a), "\n")
+	lb := strings.Split(string(b), "\n")
+	n := len(la)
+	if len(lb) > n {
+		n = len(lb)
+	}
+	var changes []Change
+	for i := 0; i < n; i++ {
+		var av, bv string
+		if i < len(la) {
+			av = la[i]
+		}
+		if i < len(lb) {
+			bv = lb[i]
+		}
+		if av != bv {
+			changes = append(changes, Change{Kind: LineChanged, Path: fmt.Sprintf("line %d", i+1), Old: av, New: bv})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,102 @@
+package svgdiff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const base = `<svg xmlns="http://www.w3.org/2000/svg">
+<rect x="0" y="0" width="10" height="10"/>
+<rect x="10" y="0" width="10" height="10"/>
+<rect x="20" y="0" width="10" height="10"/>
+<g id="a"><circle r="1"/></g>
+<g id="b"><circle r="2"/></g>
+</svg>`
+
+func TestCompare(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		b    string
+		want []Change
+	}{
+		{"identical", base, nil},
+		{
+			"attribute change",
+			strings.Replace(base, `x="10"`, `x="11"`, 1),
+			[]Change{{Kind: AttrChanged, Path: "/svg[1]/rect[2]", Attr: "x", Old: "10", New: "11"}},
+		},
+		{
+			"insertion",
+			strings.Replace(base, "<rect x=\"0\"", "<rect x=\"-10\" y=\"0\" width=\"10\" height=\"10\"/>\n<rect x=\"0\"", 1),
+			[]Change{{Kind: Added, Path: "/svg[1]/rect[1]"}},
+		},
+		{
+			"removal",
+			strings.Replace(base, `<rect x="10" y="0" width="10" height="10"/>`, "", 1),
+			[]Change{{Kind: Removed, Path: "/svg[1]/rect[2]"}},
+		},
+		{
+			"reorder",
+			strings.Replace(base, `<g id="a"><circle r="1"/></g>
+<g id="b"><circle r="2"/></g>`, `<g id="b"><circle r="2"/></g>
+<g id="a"><circle r="1"/></g>`, 1),
+			[]Change{{Kind: Moved, Path: "#a", Old: "/svg[1]/g[1]", New: "/svg[1]/g[2]"}},
+		},
+		{
+			"move between parents",
+			strings.Replace(base, `<g id="a"><circle r="1"/></g>
+<g id="b"><circle r="2"/></g>`, `<g id="a"><circle r="1"/><g id="b"><circle r="3"/></g></g>`, 1),
+			[]Change{
+				{Kind: Moved, Path: "#b", Old: "/svg[1]/g[2]", New: "/svg[1]/g[1]/g[1]"},
+				{Kind: AttrChanged, Path: "/svg[1]/g[1]/g[1]/circle[1]", Attr: "r", Old: "2", New: "3"},
+			},
+		},
+		{
+			"appended element",
+			strings.Replace(base, "</svg>", "<text>new</text></svg>", 1),
+			[]Change{{Kind: Added, Path: "/svg[1]/text[1]"}},
+		},
+	} {
+		got, err := Compare([]byte(base), []byte(c.b))
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: Compare =\n%s, want\n%s", c.name, Summary(got), Summary(c.want))
+		}
+	}
+}
+
+func TestCompareUnparseable(t *testing.T) {
+	got, err := Compare([]byte("<svg>\n<rect>\n"), []byte("<svg>\n<circle>\n"))
+	if err == nil {
+		t.Fatal("Compare of unparseable documents returned no error")
+	}
+	want := []Change{{Kind: LineChanged, Path: "line 2", Old: "<rect>", New: "<circle>"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Compare =\n%s, want\n%s", Summary(got), Summary(want))
+	}
+}
+
+func TestSummary(t *testing.T) {
+	got := Summary([]Change{
+		{Kind: AttrChanged, Path: "#a", Attr: "fill", Old: "red", New: "blue"},
+		{Kind: Added, Path: "/svg[1]/rect[1]"},
+	})
+	want := "2 change(s)\n#a: fill \"red\" -> \"blue\"\n/svg[1]/rect[1]: added\n"
+	if got != want {
+		t.Errorf("Summary = %q, want %q", got, want)
+	}
+}
+
+func TestKindString(t *testing.T) {
+	if got := AttrChanged.String(); got != "attribute changed" {
+		t.Errorf("AttrChanged.String() = %q", got)
+	}
+	for _, k := range []Kind{-1, LineChanged + 1} {
+		if got := k.String(); !strings.HasPrefix(got, "Kind(") {
+			t.Errorf("Kind(%d).String() = %q", int(k), got)
+		}
+	}
+}
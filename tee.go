@@ -0,0 +1,143 @@
+package svg
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// CallInfo describes a drawing call on a canvas made with NewTee
+type CallInfo struct {
+	Method  string // name of the SVG method called, such as "Circle", or of the helper that called it
+	Element string // name of the element drawn
+	Detail  int    // detail level set by DetailLevel
+}
+
+// tee routes the output of each drawing call to one or both of two canvases
+type tee struct {
+	primary, secondary     *SVG
+	route                  func(CallInfo) (bool, bool)
+	toPrimary, toSecondary bool
+	routed                 bool
+	held                   []route // routes of the open text elements, which their contents follow
+}
+
+// route is the choice of canvases for a call
+type route struct {
+	toPrimary, toSecondary bool
+}
+
+// NewTee is the SVG constructor for a canvas that draws on two canvases at once, sending each element
+// to the canvases chosen by route. The document itself, and containers such as groups and definitions,
+// begin and end on both; text begun with Textspan goes, with its spans, where the text was routed. A nil route sends everything to both. The primary and secondary canvases
+// should only be drawn on through the tee.
+func NewTee(primary, secondary *SVG, route func(call CallInfo) (toPrimary, toSecondary bool)) *SVG {
+	t := &tee{primary: primary, secondary: secondary, route: route}
+	t.reset()
+	return &SVG{Writer: t, tee: t}
+}
+
+// DetailLevel runs fn with the detail level n, which is passed to the routing function of a tee
+func (svg *SVG) DetailLevel(n int, fn func()) {
+	unlock := svg.lock()
+	prev := svg.detail
+	svg.detail = n
+	unlock()
+	defer func() {
+		unlock := svg.lock()
+		svg.detail = prev
+		unlock()
+	}()
+	fn()
+}
+
+// Write writes p to the canvases chosen for the current call
+func (t *tee) Write(p []byte) (int, error) {
+	var err error
+	if t.toPrimary {
+		err = write(t.primary, p)
+	}
+	if t.toSecondary {
+		if e := write(t.secondary, p); err == nil {
+			err = e
+		}
+	}
+	return len(p), err
+}
+
+// Flush writes any output buffered by either canvas
+func (t *tee) Flush() error {
+	err := t.primary.Flush()
+	if e := t.secondary.Flush(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// reset sends output to both canvases until the next element is routed, or, within text,
+// to the canvases of the text
+func (t *tee) reset() {
+	if n := len(t.held); n > 0 {
+		t.toPrimary, t.toSecondary, t.routed = t.held[n-1].toPrimary, t.held[n-1].toSecondary, true
+		return
+	}
+	t.toPrimary, t.toSecondary, t.routed = true, true, false
+}
+
+// hold keeps the route of the current call for the contents of the element it opens
+func (t *tee) hold() {
+	t.held = append(t.held, route{t.toPrimary, t.toSecondary})
+}
+
+// release drops the route of the innermost held element
+func (t *tee) release() {
+	if n := len(t.held); n > 0 {
+		t.held = t.held[:n-1]
+	}
+}
+
+// element routes the first element drawn by a call
+func (t *tee) element(tag string, detail int) {
+	if t.routed {
+		return
+	}
+	t.routed = true
+	if t.route != nil && tag != "svg" {
+		t.toPrimary, t.toSecondary = t.route(CallInfo{Method: method(), Element: tag, Detail: detail})
+	}
+}
+
+// methods prefixes the names of the methods of SVG, as the runtime reports them
+var methods = strings.TrimSuffix(runtime.FuncForPC(reflect.ValueOf(write).Pointer()).Name(), "write") + "(*SVG)."
+
+// method returns the name of the outermost exported SVG method on the stack, before the caller's code
+func method() string {
+	pc := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pc[:runtime.Callers(3, pc)])
+	name := ""
+	for {
+		frame, more := frames.Next()
+		m := strings.TrimPrefix(frame.Function, methods)
+		if m == frame.Function {
+			break
+		}
+		if r, _ := utf8.DecodeRuneInString(m); unicode.IsUpper(r) && !strings.Contains(m, ".") {
+			name = m
+		}
+		if !more {
+			break
+		}
+	}
+	return name
+}
+
+// write writes p to the writer of svg, if it has one
+func write(svg *SVG, p []byte) error {
+	if svg == nil || svg.Writer == nil {
+		return nil
+	}
+	_, err := svg.Writer.Write(p)
+	return err
+}
@@ -0,0 +1,70 @@
+package svg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTee(t *testing.T) {
+	primary, secondary := NewBuffer(), NewBuffer()
+	var calls []CallInfo
+	canvas := NewTee(primary, secondary, func(call CallInfo) (bool, bool) {
+		calls = append(calls, call)
+		return true, call.Element != "text"
+	})
+	canvas.Start(200, 100)
+	canvas.Circle(10, 10, 5)
+	canvas.Text(10, 20, "text-primary")
+	canvas.Gstyle("fill:red")
+	canvas.Circle(20, 20, 5)
+	canvas.Textspan(10, 30, "span-primary")
+	canvas.Span("inner", "fill:blue")
+	canvas.TextEnd()
+	canvas.Gend()
+	canvas.DetailLevel(2, func() {
+		canvas.Circle(30, 30, 5)
+	})
+	canvas.End()
+	for name, c := range map[string]*SVG{"tee": canvas, "primary": primary, "secondary": secondary} {
+		if err := c.Err(); err != nil {
+			t.Fatalf("%s: Err() = %v", name, err)
+		}
+	}
+
+	first, second := primary.String(), secondary.String()
+	wellformed(t, first)
+	wellformed(t, second)
+	for name, doc := range map[string]string{"primary": first, "secondary": second} {
+		if n := strings.Count(doc, "<circle"); n != 3 {
+			t.Errorf("%s: %d circles, want 3, in\n%s", name, n, doc)
+		}
+		if !strings.Contains(doc, `<g style="fill:red">`) || !strings.Contains(doc, "</g>") {
+			t.Errorf("%s: group missing from\n%s", name, doc)
+		}
+	}
+	for _, want := range []string{">text-primary</text>", ">span-primary<tspan style=\"fill:blue\">inner</tspan></text>"} {
+		if !strings.Contains(first, want) {
+			t.Errorf("primary: %s missing from\n%s", want, first)
+		}
+	}
+	if strings.Contains(second, "text") || strings.Contains(second, "inner") {
+		t.Errorf("secondary: text written in\n%s", second)
+	}
+
+	want := []CallInfo{
+		{"Circle", "circle", 0},
+		{"Text", "text", 0},
+		{"Gstyle", "g", 0},
+		{"Circle", "circle", 0},
+		{"Textspan", "text", 0},
+		{"Circle", "circle", 2},
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("routed %+v, want %+v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("call %d: %+v, want %+v", i, calls[i], want[i])
+		}
+	}
+}
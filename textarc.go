@@ -0,0 +1,86 @@
+package svg
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// ErrRightToLeft is returned when laying out right-to-left text one character at a time
+var ErrRightToLeft = errors.New("svg: right-to-left text cannot be placed by character")
+
+// TextArcChars places the text t along the arc of radius r centered at cx,cy, from startDeg to endDeg
+// (in degrees, clockwise from the positive x axis), one character at a time, each rotated along
+// the tangent of the arc. Characters are spaced by their widths, given by measure, along the arc,
+// or evenly if measure is nil. Combining marks stay with the character they follow.
+// Right-to-left text is not placed, and ErrRightToLeft returned.
+func (svg *SVG) TextArcChars(cx, cy, r int, startDeg, endDeg float64, t string, measure func(string) int, s ...string) error {
+	chars := graphemes(t)
+	for _, c := range chars {
+		if righttoleft(c) {
+			return ErrRightToLeft
+		}
+	}
+	if len(chars) == 0 {
+		return nil
+	}
+	widths := make([]float64, len(chars))
+	total := 0.0
+	for i, c := range chars {
+		widths[i] = 1
+		if measure != nil {
+			widths[i] = float64(measure(c))
+		}
+		total += widths[i]
+	}
+	if total <= 0 {
+		return nil
+	}
+	defer svg.lock()()
+	if svg.decorative(s) {
+		return nil
+	}
+	span := endDeg - startDeg
+	at := 0.0
+	for i, c := range chars {
+		a := startDeg + (at+widths[i]/2)/total*span
+		at += widths[i]
+		rad := a * math.Pi / 180
+		x, y := float64(cx)+float64(r)*math.Cos(rad), float64(cy)+float64(r)*math.Sin(rad)
+		svg.count("text")
+		svg.printf(`<text x="%.2f" y="%.2f" text-anchor="middle" transform="rotate(%.2f %.2f,%.2f)" %s`,
+			x, y, a+90, x, y, svg.endstyle(s, ">"))
+		svg.escape(c)
+		svg.println(`</text>`)
+		svg.textitem("text", c, int(math.Round(x)), int(math.Round(y)), isdecorative(s))
+	}
+	return nil
+}
+
+// zwj is the zero width joiner, which joins the characters either side of it
+const zwj = '\u200d'
+
+// graphemes splits t into characters, keeping combining marks with the preceding character,
+// and characters joined by zero width joiners together
+func graphemes(t string) []string {
+	var g []string
+	for _, r := range t {
+		if len(g) > 0 && (unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || r == zwj || strings.HasSuffix(g[len(g)-1], string(zwj))) {
+			g[len(g)-1] += string(r)
+			continue
+		}
+		g = append(g, string(r))
+	}
+	return g
+}
+
+// righttoleft determines if a character is from a right-to-left script
+func righttoleft(c string) bool {
+	for _, r := range c {
+		if unicode.In(r, unicode.Hebrew, unicode.Arabic, unicode.Syriac, unicode.Thaana, unicode.Nko) {
+			return true
+		}
+	}
+	return false
+}
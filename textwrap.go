@@ -0,0 +1,124 @@
+package svg
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// FontMetrics gives the approximate widths of characters in a font, in ems, for wrapping text
+type FontMetrics struct {
+	Widths  map[rune]float64 // widths of characters
+	Default float64          // width of characters not in Widths
+}
+
+// Approximate metrics of the generic font families, from the widths of Helvetica, Times and Courier
+var (
+	SansMetrics = asciimetrics(0.556, [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	})
+	SerifMetrics = asciimetrics(0.5, [95]int{
+		250, 333, 408, 500, 500, 833, 778, 180, 333, 333, 500, 564, 250, 333, 250, 278,
+		500, 500, 500, 500, 500, 500, 500, 500, 500, 500, 278, 278, 564, 564, 564, 444,
+		921, 722, 667, 667, 722, 611, 556, 722, 722, 333, 389, 722, 611, 889, 722, 722,
+		556, 722, 667, 556, 611, 722, 722, 944, 722, 722, 611, 333, 278, 333, 469, 500,
+		333, 444, 500, 444, 500, 444, 333, 500, 500, 278, 278, 500, 278, 778, 500, 500,
+		500, 500, 333, 389, 278, 500, 500, 722, 500, 500, 444, 480, 200, 480, 541,
+	})
+	MonoMetrics = FontMetrics{Default: 0.6}
+)
+
+// textwrapleading is the distance between wrapped lines, in ems
+const textwrapleading = 1.2
+
+// asciimetrics returns the metrics with the widths, in thousandths of an em,
+// of the printable ASCII characters, and the default width def
+func asciimetrics(def float64, widths [95]int) FontMetrics {
+	m := FontMetrics{Widths: make(map[rune]float64, len(widths)), Default: def}
+	for i, w := range widths {
+		m.Widths[rune(' '+i)] = float64(w) / 1000
+	}
+	return m
+}
+
+// Width returns the approximate width of s at the font size. Combining marks take no width,
+// and characters of East Asian scripts a full em.
+func (m FontMetrics) Width(s string, size float64) float64 {
+	var w float64
+	for _, r := range s {
+		switch {
+		case unicode.In(r, unicode.Mn, unicode.Me) || r == zwj:
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			w++
+		default:
+			if cw, ok := m.Widths[r]; ok {
+				w += cw
+			} else {
+				w += m.Default
+			}
+		}
+	}
+	return w * size
+}
+
+// SetFontMetrics sets the metrics used by Textwrap to measure text; the default is SansMetrics
+func (svg *SVG) SetFontMetrics(m FontMetrics) { svg.metrics = &m }
+
+// Textwrap places the paragraph t as text at x,y, at the font size in pixels, with optional style,
+// wrapped into lines no wider than width, as measured by the canvas font metrics, and returns
+// the number of lines. Lines are broken between words; words wider than width are broken
+// between characters. Newlines in t begin new lines; a width that is not positive only those.
+func (svg *SVG) Textwrap(x, y, width int, t string, fontsize int, s ...string) int {
+	unlock := svg.lock()
+	m := SansMetrics
+	if svg.metrics != nil {
+		m = *svg.metrics
+	}
+	unlock()
+	var lines []string
+	for _, p := range strings.Split(t, "\n") {
+		lines = append(lines, wrap(strings.TrimSuffix(p, "\r"), float64(width), float64(fontsize), m)...)
+	}
+	text := strings.Join(lines, "\n")
+	svg.TextMultiline(x, y, text, textwrapleading, append([]string{fmt.Sprintf("font-size:%dpx", fontsize)}, s...)...)
+	if text == "" {
+		return 0
+	}
+	return len(lines)
+}
+
+// wrap breaks the paragraph p greedily into lines no wider than width, at the font size
+func wrap(p string, width, size float64, m FontMetrics) []string {
+	words := strings.Fields(p)
+	if len(words) == 0 {
+		return []string{""}
+	}
+	if width <= 0 {
+		return []string{strings.Join(words, " ")}
+	}
+	var lines []string
+	line := ""
+	for _, word := range words {
+		if line != "" {
+			if m.Width(line+" "+word, size) <= width {
+				line += " " + word
+				continue
+			}
+			lines = append(lines, line)
+		}
+		line = ""
+		for _, c := range graphemes(word) {
+			if line != "" && m.Width(line+c, size) > width {
+				lines = append(lines, line)
+				line = ""
+			}
+			line += c
+		}
+	}
+	return append(lines, line)
+}
@@ -0,0 +1,53 @@
+package svg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+)
+
+// TiledImage fills the region at x,y with dimension w,h with copies of img, each tileW wide,
+// and as high as the image's aspect ratio requires, with optional style. The image is embedded
+// in a pattern as a data URI, downscaled to the tile size if larger, and the pattern id returned.
+// Nothing is drawn for empty images or tile widths, or images that cannot be encoded,
+// and the id is empty.
+func (svg *SVG) TiledImage(x, y, w, h int, img image.Image, tileW int, s ...string) string {
+	b := img.Bounds()
+	if b.Empty() || tileW <= 0 {
+		return ""
+	}
+	tileH := int(math.Max(1, math.Round(float64(tileW)*float64(b.Dy())/float64(b.Dx()))))
+	if b.Dx() > tileW || b.Dy() > tileH {
+		img = downscale(img, tileW, tileH)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return ""
+	}
+	unlock := svg.lock()
+	id := svg.uid("tile")
+	unlock()
+	svg.Pattern(id, x, y, tileW, tileH, "user")
+	svg.Image(0, 0, tileW, tileH, "data:image/png;base64,"+base64.StdEncoding.EncodeToString(buf.Bytes()),
+		`preserveAspectRatio="none"`)
+	svg.PatternEnd()
+	svg.Rect(x, y, w, h, append([]string{fmt.Sprintf("fill:url(#%s)", id)}, s...)...)
+	return id
+}
+
+// downscale resizes img to dimension w,h, by nearest neighbor sampling
+func downscale(img image.Image, w, h int) image.Image {
+	b := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for j := 0; j < h; j++ {
+		sy := b.Min.Y + (2*j+1)*b.Dy()/(2*h)
+		for i := 0; i < w; i++ {
+			sx := b.Min.X + (2*i+1)*b.Dx()/(2*w)
+			dst.Set(i, j, img.At(sx, sy))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,81 @@
+package svg
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Transform is a list of transformations, applied in the order they were added.
+// The zero value is the identity; each method returns the list with a transformation added.
+type Transform struct {
+	ops []transformop
+}
+
+// transformop is a transformation function and its arguments
+type transformop struct {
+	name string
+	args []float64
+}
+
+// Translate adds a translation by x,y
+func (t Transform) Translate(x, y float64) Transform { return t.add("translate", x, y) }
+
+// Rotate adds a rotation by a degrees about the origin
+func (t Transform) Rotate(a float64) Transform { return t.add("rotate", a) }
+
+// RotateAbout adds a rotation by a degrees about the point cx,cy
+func (t Transform) RotateAbout(a, cx, cy float64) Transform { return t.add("rotate", a, cx, cy) }
+
+// Scale adds scaling by s
+func (t Transform) Scale(s float64) Transform { return t.add("scale", s) }
+
+// ScaleXY adds scaling by sx horizontally and sy vertically
+func (t Transform) ScaleXY(sx, sy float64) Transform { return t.add("scale", sx, sy) }
+
+// SkewX adds a skew along the x axis by a degrees
+func (t Transform) SkewX(a float64) Transform { return t.add("skewX", a) }
+
+// SkewY adds a skew along the y axis by a degrees
+func (t Transform) SkewY(a float64) Transform { return t.add("skewY", a) }
+
+// Matrix adds the transformation matrix [a c e, b d f, 0 0 1]
+func (t Transform) Matrix(a, b, c, d, e, f float64) Transform {
+	return t.add("matrix", a, b, c, d, e, f)
+}
+
+// String returns the value of the transform attribute, with values at full precision
+func (t Transform) String() string {
+	return t.format(func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) })
+}
+
+// GtransformT begins a group with the transform t, with values at the canvas precision, end with Gend()
+func (svg *SVG) GtransformT(t Transform) {
+	unlock := svg.lock()
+	s := t.format(svg.num)
+	unlock()
+	svg.Gtransform(s)
+}
+
+// add returns the list with the transformation name added, leaving t unchanged
+func (t Transform) add(name string, args ...float64) Transform {
+	return Transform{ops: append(t.ops[:len(t.ops):len(t.ops)], transformop{name, args})}
+}
+
+// format returns the transform list, with values formatted by num
+func (t Transform) format(num func(float64) string) string {
+	var b strings.Builder
+	for i, op := range t.ops {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(op.name + "(")
+		for j, v := range op.args {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(num(v))
+		}
+		b.WriteByte(')')
+	}
+	return b.String()
+}
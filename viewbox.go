@@ -0,0 +1,75 @@
+package svg
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// ViewBox is the rectangle of user space mapped to the viewport
+type ViewBox struct {
+	MinX, MinY, W, H int
+}
+
+// String returns the value of the viewBox attribute
+func (vb ViewBox) String() string { return fmt.Sprintf("%d %d %d %d", vb.MinX, vb.MinY, vb.W, vb.H) }
+
+// ZoomAt zooms in by factor (or out, for factors below 1), keeping the point cx,cy stationary.
+// Factors that are not positive leave the view box unchanged; its size is limited
+// to between one unit and math.MaxInt32.
+func (vb ViewBox) ZoomAt(cx, cy int, factor float64) ViewBox {
+	if factor <= 0 || math.IsInf(factor, 0) || math.IsNaN(factor) {
+		return vb
+	}
+	w := math.Min(math.MaxInt32, math.Max(1, math.Round(float64(vb.W)/factor)))
+	h := math.Min(math.MaxInt32, math.Max(1, math.Round(float64(vb.H)/factor)))
+	fx, fy := 0.5, 0.5
+	if vb.W != 0 {
+		fx = float64(cx-vb.MinX) / float64(vb.W)
+	}
+	if vb.H != 0 {
+		fy = float64(cy-vb.MinY) / float64(vb.H)
+	}
+	return ViewBox{
+		MinX: int(math.Round(float64(cx) - fx*w)),
+		MinY: int(math.Round(float64(cy) - fy*h)),
+		W:    int(w),
+		H:    int(h),
+	}
+}
+
+// Pan moves the view box by dx,dy
+func (vb ViewBox) Pan(dx, dy int) ViewBox {
+	vb.MinX += dx
+	vb.MinY += dy
+	return vb
+}
+
+// FitRect returns the view box showing just r, with margin on each side
+func (vb ViewBox) FitRect(r image.Rectangle, margin int) ViewBox {
+	r = r.Canon()
+	return ViewBox{MinX: r.Min.X - margin, MinY: r.Min.Y - margin, W: r.Dx() + 2*margin, H: r.Dy() + 2*margin}
+}
+
+// AspectCorrect widens or heightens the view box, about its center, to the aspect ratio
+// (width divided by height) targetWH, so that it is not distorted in a viewport of that shape
+func (vb ViewBox) AspectCorrect(targetWH float64) ViewBox {
+	if targetWH <= 0 || vb.H <= 0 || vb.W <= 0 {
+		return vb
+	}
+	if float64(vb.W)/float64(vb.H) < targetWH {
+		w := int(math.Round(float64(vb.H) * targetWH))
+		vb.MinX -= (w - vb.W) / 2
+		vb.W = w
+	} else {
+		h := int(math.Round(float64(vb.W) / targetWH))
+		vb.MinY -= (h - vb.H) / 2
+		vb.H = h
+	}
+	return vb
+}
+
+// StartviewBox begins the SVG document, with the specified width, height, and view box
+func (svg *SVG) StartviewBox(w, h int, vb ViewBox, ns ...string) {
+	svg.Start(w, h, append([]string{fmt.Sprintf(vbfmt, vb.MinX, vb.MinY, vb.W, vb.H)}, ns...)...)
+}